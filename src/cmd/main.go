@@ -3,28 +3,124 @@ package main
 import (
 	"MB-test/src/configs"
 	"MB-test/src/internal/controller"
+	"MB-test/src/internal/engine"
 	"MB-test/src/internal/repository"
+	"MB-test/src/internal/scheduler"
 	"MB-test/src/internal/service"
+	"MB-test/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ShutdownTimeout is how long the requests in flight have to finish on shutdown.
+const ShutdownTimeout = 10 * time.Second
+
 func main() {
+	if err := run(); err != nil {
+		log.Fatalf("Failed to run the server: %v", err)
+	}
+}
 
+// run serves the API until SIGINT/SIGTERM or until the server fails, and returns only
+// after the scheduler and the engine have stopped.
+func run() error {
 	env := configs.LoadEnv()
 	db := configs.NewDatabase(env)
 	configs.MigrateDb(db)
 	configs.Seeders(db)
 
-	repo := repository.NewRepository(db)
-	svc := service.NewService(repo)
+	repo := repository.NewRepository(db, models.FeeSchedule{
+		MakerRate: env.MAKER_FEE_RATE,
+		TakerRate: env.TAKER_FEE_RATE,
+	})
+
+	// The notional limits configured per quote asset replace the default ones.
+	policy := models.DefaultScorePolicy
+	for quote, limits := range env.NOTIONAL_LIMITS {
+		policy = policy.WithNotionalLimits(quote, limits)
+	}
+	repo.Policy = policy
+
+	eng := engine.NewEngine(repo)
+	if err := eng.Start(); err != nil {
+		panic(fmt.Sprintf("Failed to load the order book: %v", err))
+	}
+	defer eng.Stop()
+
+	svc := service.NewService(repo, eng)
+	svc.Policy = policy
 	ctl := controller.NewController(svc)
 
+	expiry := scheduler.NewScheduler(svc, env.ORDER_EXPIRY_INTERVAL, env.ORDER_MAX_AGE)
+	expiry.Start()
+	defer expiry.Stop()
+
 	router := gin.New()
 	router.POST("/orders", ctl.CreateOrder)
+	router.POST("/orders/batch", ctl.CreateOrderBatch)
+	router.POST("/orders/oco", ctl.CreateOcoOrder)
 	router.PATCH("/orders/:orderId/status/:status", ctl.UpdateStatusOrder)
+	router.PATCH("/orders/:orderId", ctl.AmendOrder)
 	router.GET("/orders", ctl.ListOrders)
+	router.POST("/clients", ctl.CreateClient)
+	router.GET("/clients", ctl.ListClients)
 	router.GET("/client/:id", ctl.GetClientById)
+	router.PATCH("/client/:id", ctl.UpdateClient)
+	router.GET("/book", ctl.GetBook)
+	router.POST("/markets", ctl.CreateMarket)
+	router.GET("/markets", ctl.ListMarkets)
+	router.GET("/trades", ctl.ListTrades)
+	router.GET("/client/:id/trades", ctl.ListClientTrades)
+	router.DELETE("/client/:id/orders", ctl.CancelClientOrders)
+	router.POST("/client/:id/deposits", ctl.Deposit)
+	router.POST("/client/:id/withdrawals", ctl.RequestWithdrawal)
+	router.GET("/client/:id/ledger", ctl.ListClientLedger)
+	router.GET("/ledger/reconciliation", ctl.ReconcileLedger)
+	router.GET("/withdrawals", ctl.ListWithdrawals)
+	router.PATCH("/withdrawals/:withdrawalId/approve", ctl.ApproveWithdrawal)
+	router.PATCH("/withdrawals/:withdrawalId/reject", ctl.RejectWithdrawal)
+	router.PATCH("/withdrawals/:withdrawalId/send", ctl.SendWithdrawal)
+
+	// Same address gin uses by default: $PORT or 8080.
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	server := &http.Server{Addr: ":" + port, Handler: router}
+
+	failed := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	// On SIGINT/SIGTERM, or when the server fails, stop taking requests and let the ones
+	// in flight finish, then the deferred Stops wait for the expiry scheduler and the
+	// engine to finish too.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	var failure error
+	select {
+	case <-quit:
+	case failure = <-failed:
+	}
+
+	log.Println("shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down the server: %v\n", err)
+	}
 
-	router.Run()
+	return failure
 }
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Database struct {
@@ -35,10 +37,244 @@ func NewDatabase(env Env) *gorm.DB {
 }
 
 func MigrateDb(db *gorm.DB) {
-	err := db.AutoMigrate(&models.Client{}, &models.Orders{})
+	hasHolds := !db.Migrator().HasTable(&models.Orders{}) || db.Migrator().HasColumn(&models.Orders{}, "held_amount")
+	hasLedger := db.Migrator().HasTable(&models.LedgerEntry{})
+
+	if err := migrateMoneyToNumeric(db); err != nil {
+		panic(fmt.Sprintf("Erro na migração dos valores para decimal: %v", err))
+	}
+
+	err := db.AutoMigrate(&models.Market{}, &models.Client{}, &models.Balance{}, &models.Orders{}, &models.Trade{}, &models.Journal{}, &models.LedgerEntry{}, &models.Withdrawal{})
 	if err != nil {
 		panic("Erro na migração")
 	}
+
+	if err := createDefaultMarkets(db); err != nil {
+		panic(fmt.Sprintf("Erro na criação dos mercados padrão: %v", err))
+	}
+
+	if err := migrateOrdersToUnitPrice(db); err != nil {
+		panic(fmt.Sprintf("Erro na migração das ordens para preço unitário: %v", err))
+	}
+
+	if err := migrateClientBalances(db); err != nil {
+		panic(fmt.Sprintf("Erro na migração dos saldos para a tabela de saldos: %v", err))
+	}
+
+	if !hasHolds {
+		if err := backfillOrderHolds(db); err != nil {
+			panic(fmt.Sprintf("Erro na migração das reservas de saldo: %v", err))
+		}
+	}
+
+	if !hasLedger {
+		if err := backfillLedger(db); err != nil {
+			panic(fmt.Sprintf("Erro na migração dos saldos para o razão: %v", err))
+		}
+	}
+}
+
+// createDefaultMarkets lists the default markets that do not exist yet. Orders created
+// before markets existed belong to models.DefaultMarket.
+func createDefaultMarkets(db *gorm.DB) error {
+	for _, market := range models.DefaultMarkets {
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&market).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateClientBalances moves the BRL and BT balances kept in columns of the clients
+// to the balances table, one row per client and asset, and drops the old columns.
+func migrateClientBalances(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Client{}, "balance_brl") {
+		return nil
+	}
+
+	log.Println("migrating client balances to the balances table...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Clients from before holds existed have no held columns.
+		heldBRL, heldBT := "0", "0"
+		if migrator.HasColumn(&models.Client{}, "held_brl") {
+			heldBRL, heldBT = "held_brl", "held_bt"
+		}
+
+		if err := tx.Exec(`INSERT INTO balances (client_id, asset, available, held)
+			SELECT id, ?, balance_brl, `+heldBRL+` FROM clients
+			UNION ALL
+			SELECT id, ?, balance_bt, `+heldBT+` FROM clients
+			ON CONFLICT DO NOTHING`, models.AssetBRL, models.AssetBT).Error; err != nil {
+			return err
+		}
+
+		for _, column := range []string{"balance_brl", "balance_bt", "held_brl", "held_bt"} {
+			if !tx.Migrator().HasColumn(&models.Client{}, column) {
+				continue
+			}
+			if err := tx.Migrator().DropColumn(&models.Client{}, column); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// backfillLedger records the balances clients had before the ledger existed as their
+// opening journals, so the ledger accounts start reconciled with the client balances.
+func backfillLedger(db *gorm.DB) error {
+	log.Println("recording opening balances in the ledger...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		clients := []models.Client{}
+		if err := tx.Preload("Balances").Find(&clients).Error; err != nil {
+			return err
+		}
+
+		for _, client := range clients {
+			journal := models.NewOpeningJournal(client)
+			if len(journal.Entries) == 0 {
+				continue
+			}
+			if err := tx.Create(&journal).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// backfillOrderHolds reserves the balance of orders that were resting in the book
+// before holds existed. Orders whose owner can no longer cover them are cancelled.
+func backfillOrderHolds(db *gorm.DB) error {
+	log.Println("reserving balance of resting orders...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		orders := []models.Orders{}
+		if err := tx.Where("status IN ?", []int{models.OPEN, models.WAITING, models.PARTIALLY_FILLED}).
+			Order("created_at ASC").
+			Find(&orders).Error; err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			hold := order.HoldFor(order.RemainingQuantity())
+
+			result := tx.Model(&models.Balance{}).
+				Where("client_id = ? AND asset = ?", order.OwnerOrderId, order.HoldAsset()).
+				Where("available >= ?", hold).
+				Updates(map[string]interface{}{
+					"available": gorm.Expr("available - ?", hold),
+					"held":      gorm.Expr("held + ?", hold),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				log.Printf("Cancelling order %v: insufficient balance to reserve %v\n", order.Id, hold)
+				if err := tx.Model(&order).Update("status", models.CANCEL).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Model(&order).Update("held_amount", hold).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// migrateMoneyToNumeric converts the money columns stored as double precision
+// into exact numeric columns, rounding to centavos (BRL) and satoshis (BT).
+func migrateMoneyToNumeric(db *gorm.DB) error {
+	columns := []struct {
+		model  interface{}
+		table  string
+		column string
+		places int
+	}{
+		{&models.Client{}, "clients", "balance_brl", models.BRLPrecision},
+		{&models.Client{}, "clients", "balance_bt", models.BTPrecision},
+		{&models.Client{}, "clients", "held_brl", models.BRLPrecision},
+		{&models.Client{}, "clients", "held_bt", models.BTPrecision},
+		{&models.Orders{}, "orders", "price", models.BRLPrecision},
+		{&models.Orders{}, "orders", "quantity", models.BTPrecision},
+		{&models.Orders{}, "orders", "filled_quantity", models.BTPrecision},
+		{&models.Orders{}, "orders", "held_amount", models.BTPrecision},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range columns {
+			if !tx.Migrator().HasTable(c.model) {
+				continue
+			}
+
+			columnTypes, err := tx.Migrator().ColumnTypes(c.model)
+			if err != nil {
+				return err
+			}
+
+			for _, columnType := range columnTypes {
+				if columnType.Name() != c.column || columnType.DatabaseTypeName() != "float8" {
+					continue
+				}
+
+				log.Printf("migrating %s.%s to numeric...\n", c.table, c.column)
+				if err := tx.Exec(fmt.Sprintf(
+					"ALTER TABLE %s ALTER COLUMN %s TYPE numeric(36,18) USING round(%s::numeric, %d)",
+					c.table, c.column, c.column, c.places,
+				)).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// migrateOrdersToUnitPrice converts orders stored as total BRL/total BT pairs
+// (price_order_brl, price_order_bt, filled_order_bt) into limit price and quantity.
+func migrateOrdersToUnitPrice(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Orders{}, "price_order_brl") {
+		return nil
+	}
+
+	log.Println("migrating orders to unit price...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		filled := "0"
+		if migrator.HasColumn(&models.Orders{}, "filled_order_bt") {
+			filled = "COALESCE(filled_order_bt, 0)"
+		}
+
+		if err := tx.Exec(`UPDATE orders SET
+			price = CASE WHEN price_order_bt > 0 THEN round((price_order_brl / price_order_bt)::numeric, 2) ELSE 0 END,
+			quantity = round(price_order_bt::numeric, 8),
+			filled_quantity = round((` + filled + `)::numeric, 8)`).Error; err != nil {
+			return err
+		}
+
+		for _, column := range []string{"price_order_brl", "price_order_bt", "filled_order_bt"} {
+			if !tx.Migrator().HasColumn(&models.Orders{}, column) {
+				continue
+			}
+			if err := tx.Migrator().DropColumn(&models.Orders{}, column); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func Seeders(db *gorm.DB) {
@@ -46,44 +282,67 @@ func Seeders(db *gorm.DB) {
 
 	clients := []models.Client{
 		{
-			Id:         uuid.MustParse("b7050560-3387-4318-812d-f671ae9caa6e"),
-			BalanceBRL: 12533,
-			BalanceBT:  4,
-			Score:      70,
-			CreatedAt:  time.Now(),
+			Id: uuid.MustParse("b7050560-3387-4318-812d-f671ae9caa6e"),
+			Balances: []models.Balance{
+				{Asset: models.AssetBRL, Available: decimal.NewFromInt(12533)},
+				{Asset: models.AssetBT, Available: decimal.NewFromInt(4)},
+			},
+			Score:     70,
+			CreatedAt: time.Now(),
 		},
 		{
-			Id:         uuid.MustParse("2268237d-1079-47e8-b7b2-8ab9ae1942f5"),
-			BalanceBRL: 994533,
-			BalanceBT:  12,
-			Score:      99,
-			CreatedAt:  time.Now(),
+			Id: uuid.MustParse("2268237d-1079-47e8-b7b2-8ab9ae1942f5"),
+			Balances: []models.Balance{
+				{Asset: models.AssetBRL, Available: decimal.NewFromInt(994533)},
+				{Asset: models.AssetBT, Available: decimal.NewFromInt(12)},
+			},
+			Score:     99,
+			CreatedAt: time.Now(),
 		},
 		{
-			Id:         uuid.MustParse("d3909b31-045b-4c3e-a6f8-2edb54316b37"),
-			BalanceBRL: 18485,
-			BalanceBT:  7,
-			Score:      95,
-			CreatedAt:  time.Now(),
+			Id: uuid.MustParse("d3909b31-045b-4c3e-a6f8-2edb54316b37"),
+			Balances: []models.Balance{
+				{Asset: models.AssetBRL, Available: decimal.NewFromInt(18485)},
+				{Asset: models.AssetBT, Available: decimal.NewFromInt(7)},
+			},
+			Score:     95,
+			CreatedAt: time.Now(),
 		},
 		{
-			Id:         uuid.MustParse("e65d206b-aa5c-4d47-8684-672b2bc8a826"),
-			BalanceBRL: 985,
-			BalanceBT:  2,
-			Score:      62,
-			CreatedAt:  time.Now(),
+			Id: uuid.MustParse("e65d206b-aa5c-4d47-8684-672b2bc8a826"),
+			Balances: []models.Balance{
+				{Asset: models.AssetBRL, Available: decimal.NewFromInt(985)},
+				{Asset: models.AssetBT, Available: decimal.NewFromInt(2)},
+			},
+			Score:     62,
+			CreatedAt: time.Now(),
 		},
 		{
-			Id:         uuid.MustParse("dc333741-4adc-4e28-89a1-f0e45d38b2db"),
-			BalanceBRL: 62875,
-			BalanceBT:  35,
-			Score:      100,
-			CreatedAt:  time.Now(),
+			Id: uuid.MustParse("dc333741-4adc-4e28-89a1-f0e45d38b2db"),
+			Balances: []models.Balance{
+				{Asset: models.AssetBRL, Available: decimal.NewFromInt(62875)},
+				{Asset: models.AssetBT, Available: decimal.NewFromInt(35)},
+			},
+			Score:     100,
+			CreatedAt: time.Now(),
 		},
 	}
 
 	for _, client := range clients {
-		if err := db.FirstOrCreate(&client, models.Client{Id: client.Id}).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ?", client.Id).Limit(1).Find(&models.Client{})
+			if result.Error != nil || result.RowsAffected > 0 {
+				return result.Error
+			}
+
+			if err := tx.Create(&client).Error; err != nil {
+				return err
+			}
+
+			journal := models.NewOpeningJournal(client)
+			return tx.Create(&journal).Error
+		})
+		if err != nil {
 			log.Printf("Error seeding client %v: %v\n", client.Id, err)
 		}
 	}
@@ -1,8 +1,14 @@
 package configs
 
 import (
+	"MB-test/src/models"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Env struct {
@@ -12,11 +18,79 @@ type Env struct {
 	POSTGRES_HOST     string
 	POSTGRES_PORT     int
 	SSLMODE           string
+
+	ORDER_EXPIRY_INTERVAL time.Duration // Intervalo entre as execuções da expiração de ordens
+	ORDER_MAX_AGE         time.Duration // Tempo máximo de uma ordem no livro, 0 desativa
+
+	MAKER_FEE_RATE decimal.Decimal // Taxa da ordem que estava no livro, fração do valor recebido
+	TAKER_FEE_RATE decimal.Decimal // Taxa da ordem que cruzou o livro, fração do valor recebido
+
+	NOTIONAL_LIMITS map[string][]decimal.Decimal // Valor máximo de uma proposta por ativo de cotação, um por faixa de score
+}
+
+// parseFeeRate reads a fee rate between 0 and 1, falling back to zero when it is
+// missing or invalid.
+func parseFeeRate(value string) decimal.Decimal {
+	rate, err := decimal.NewFromString(value)
+	if err != nil || rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(1)) {
+		return decimal.Zero
+	}
+	return rate
+}
+
+// parseNotionalLimits reads the notional limits of the score bands per quote asset, as
+// in "USDT=200000/50000/20000/4000;ETH=100/25/10/2", one limit per band from the
+// highest score to the lowest. Invalid assets are skipped.
+func parseNotionalLimits(value string) map[string][]decimal.Decimal {
+	limits := map[string][]decimal.Decimal{}
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		asset, values, _ := strings.Cut(entry, "=")
+		parsed, ok := parseLimits(values)
+		if !models.ValidAsset(asset) || !ok {
+			log.Printf("Ignoring the notional limits %q: inform an asset and %d positive limits\n", entry, len(models.DefaultScorePolicy))
+			continue
+		}
+		limits[asset] = parsed
+	}
+
+	return limits
+}
+
+// parseLimits reads one positive limit per score band, separated by slashes.
+func parseLimits(value string) ([]decimal.Decimal, bool) {
+	fields := strings.Split(value, "/")
+	if len(fields) != len(models.DefaultScorePolicy) {
+		return nil, false
+	}
+
+	limits := make([]decimal.Decimal, 0, len(fields))
+	for _, field := range fields {
+		limit, err := decimal.NewFromString(strings.TrimSpace(field))
+		if err != nil || !limit.IsPositive() {
+			return nil, false
+		}
+		limits = append(limits, limit)
+	}
+
+	return limits, true
 }
 
 func LoadEnv() Env {
 	envPort := os.Getenv("POSTGRES_PORT")
 	port, _ := strconv.Atoi(envPort)
+
+	expiryInterval, err := time.ParseDuration(os.Getenv("ORDER_EXPIRY_INTERVAL"))
+	if err != nil || expiryInterval <= 0 {
+		expiryInterval = time.Minute
+	}
+	maxAge, _ := time.ParseDuration(os.Getenv("ORDER_MAX_AGE"))
+
 	return Env{
 		POSTGRES_DB:       os.Getenv("POSTGRES_DB"),
 		POSTGRES_USER:     os.Getenv("POSTGRES_USER"),
@@ -24,5 +98,13 @@ func LoadEnv() Env {
 		POSTGRES_HOST:     os.Getenv("POSTGRES_HOST"),
 		POSTGRES_PORT:     port,
 		SSLMODE:           os.Getenv("SSLMODE"),
+
+		ORDER_EXPIRY_INTERVAL: expiryInterval,
+		ORDER_MAX_AGE:         maxAge,
+
+		MAKER_FEE_RATE: parseFeeRate(os.Getenv("MAKER_FEE_RATE")),
+		TAKER_FEE_RATE: parseFeeRate(os.Getenv("TAKER_FEE_RATE")),
+
+		NOTIONAL_LIMITS: parseNotionalLimits(os.Getenv("NOTIONAL_LIMITS")),
 	}
 }
@@ -2,23 +2,81 @@ package contracts
 
 import (
 	"MB-test/src/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type OperationsServiceHandler interface {
 	CreateOrder(order models.Orders) (string, error)
+	CreateOrderBatch(batch models.OrderBatchDtoInput) ([]models.OrderBatchResult, error)
+	CreateOcoOrder(input models.OrderOcoDtoInput) ([]uuid.UUID, error)
 	ListOrders() ([]models.OrderDtoOutput, error)
 	GetClientById(id string) (models.ClientDtoOutput, error)
+	CreateClient(client models.ClientDtoInput) (models.ClientDtoOutput, error)
+	ListClients(page models.Pagination) (models.ClientPageDtoOutput, error)
+	UpdateClient(id string, update models.ClientUpdateDtoInput) (models.ClientDtoOutput, error)
+	Deposit(clientId string, movement models.LedgerMovementDtoInput) (models.Journal, error)
+	RequestWithdrawal(clientId string, movement models.LedgerMovementDtoInput) (models.Withdrawal, error)
+	ListWithdrawals(status string, page models.Pagination) (models.WithdrawalPageDtoOutput, error)
+	ApproveWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error)
+	RejectWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error)
+	SendWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error)
+	ListClientLedger(clientId string, page models.Pagination) (models.LedgerPageDtoOutput, error)
+	ReconcileLedger() ([]models.LedgerMismatch, error)
 	UpdateStatusOrder(status int, orderId string) (string, error)
+	AmendOrder(orderId string, amendment models.OrderAmendDtoInput) (string, error)
+	CancelClientOrders(clientId string, filter models.OrderCancelFilter) ([]uuid.UUID, error)
+	ListTrades(filter models.TradeFilter) ([]models.TradeDtoOutput, error)
+	ListClientTrades(clientId string, filter models.TradeFilter) ([]models.TradeDtoOutput, error)
+	GetBook(market string, depth int) (models.BookDtoOutput, error)
+	CreateMarket(input models.MarketDtoInput) (models.Market, error)
+	ListMarkets() ([]models.Market, error)
 }
 
 type OperationsRepositoryHandle interface {
 	CreateOrder(order models.Orders) (models.Orders, error)
-	UpdateStatusOrder(status int, orderId string) (models.Orders, error)
+	CreateOrders(orders []models.Orders) (int, error)
+	UpdateStatusOrder(status int, orderId string, reason string) (models.Orders, error)
+	AmendOrder(order models.Orders) (models.Orders, error)
+	CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error)
 	GetClientById(id string) (models.Client, error)
+	CreateClient(client models.Client) (models.Client, error)
+	ListClients(page models.Pagination) ([]models.Client, int64, error)
+	UpdateClientScore(id string, score int) (models.Client, error)
+	Deposit(clientId string, asset string, amount decimal.Decimal) (models.Journal, error)
+	RequestWithdrawal(clientId string, asset string, amount decimal.Decimal) (models.Withdrawal, error)
+	GetWithdrawalById(id string) (models.Withdrawal, error)
+	ListWithdrawals(statuses []string, page models.Pagination) ([]models.Withdrawal, int64, error)
+	UpdateWithdrawal(previous, updated models.Withdrawal) (models.Withdrawal, error)
+	ListLedgerEntries(clientId string, page models.Pagination) ([]models.LedgerEntry, int64, error)
+	ReconcileLedger() ([]models.LedgerMismatch, error)
 	ListOrders() ([]models.Orders, error)
 	GetOrderById(id string) (models.Orders, error)
-	FindMatchOrderToSell(order models.Orders) (models.Orders, error)
-	FindMatchOrderToBuy(order models.Orders) (models.Orders, error)
-	MakeTransactionBuy(buyOrder, sellOrder models.Orders) error
-	MakeTransactionSell(buyOrder, sellOrder models.Orders) error
+	CountOpenOrders(clientId string) (int64, error)
+	ListRestingOrders() ([]models.Orders, error)
+	ListPendingStopOrders() ([]models.Orders, error)
+	ListLastPrices() (map[string]decimal.Decimal, error)
+	TriggerOrder(order models.Orders) (models.Orders, error)
+	MakeTransactionBuy(buyOrder, sellOrder models.Orders, quantity decimal.Decimal) error
+	MakeTransactionSell(buyOrder, sellOrder models.Orders, quantity decimal.Decimal) error
+	ListTrades(filter models.TradeFilter) ([]models.Trade, error)
+	ListOrdersToExpire(now time.Time, staleBefore *time.Time) ([]models.Orders, error)
+	CreateMarket(market models.Market) (models.Market, error)
+	GetMarket(symbol string) (models.Market, error)
+	ListMarkets() ([]models.Market, error)
+}
+
+type MatchingEngineHandler interface {
+	Submit(order models.Orders) (models.Orders, error)
+	SubmitBatch(orders []models.Orders) ([]models.Orders, int, error)
+	UpdateStatus(orderId string, status int, reason string) (models.Orders, error)
+	Amend(orderId string, price, quantity decimal.Decimal) (models.Orders, error)
+	CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error)
+	Book(market string, depth int) (models.BookDtoOutput, error)
+}
+
+type OrderExpirationHandler interface {
+	ExpireOrders(now time.Time, maxAge time.Duration) (int, error)
 }
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type Controller struct {
@@ -18,6 +20,119 @@ func NewController(service contracts.OperationsServiceHandler) Controller {
 	return Controller{Service: service}
 }
 
+// respondError writes the error as JSON, using the status code of models.Error when available.
+func respondError(ctx *gin.Context, err error) {
+	var appErr models.Error
+	if errors.As(err, &appErr) {
+		ctx.JSON(appErr.StatusCode, gin.H{
+			"error":     appErr.Message,
+			"kind":      appErr.Kind,
+			"status":    appErr.StatusCode,
+			"retryable": appErr.Retryable(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, gin.H{
+		"error":     err.Error(),
+		"kind":      models.ErrorKindInternal,
+		"status":    models.StatusCodeInternal,
+		"retryable": false,
+	})
+}
+
+// parseTimeQuery reads an optional RFC3339 timestamp from the query string.
+func parseTimeQuery(ctx *gin.Context, key string) (*time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// parseTradeFilter reads the market and the from/to time range of the trade history endpoints.
+func parseTradeFilter(ctx *gin.Context) (models.TradeFilter, error) {
+	from, err := parseTimeQuery(ctx, "from")
+	if err != nil {
+		return models.TradeFilter{}, err
+	}
+
+	to, err := parseTimeQuery(ctx, "to")
+	if err != nil {
+		return models.TradeFilter{}, err
+	}
+
+	return models.TradeFilter{Market: ctx.Query("market"), From: from, To: to}, nil
+}
+
+// parseDecimalQuery reads an optional decimal from the query string.
+func parseDecimalQuery(ctx *gin.Context, key string) (*decimal.Decimal, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// parseOrderCancelFilter reads the market, side and price range of the bulk cancel endpoint.
+func parseOrderCancelFilter(ctx *gin.Context) (models.OrderCancelFilter, error) {
+	filter := models.OrderCancelFilter{Market: ctx.Query("market")}
+
+	if t := ctx.Query("type_order"); t != "" {
+		typeOrder, err := strconv.Atoi(t)
+		if err != nil {
+			return models.OrderCancelFilter{}, err
+		}
+		filter.TypeOrder = typeOrder
+	}
+
+	var err error
+	if filter.MinPrice, err = parseDecimalQuery(ctx, "min_price"); err != nil {
+		return models.OrderCancelFilter{}, err
+	}
+
+	if filter.MaxPrice, err = parseDecimalQuery(ctx, "max_price"); err != nil {
+		return models.OrderCancelFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// parsePagination reads the optional page and page_size of a listing.
+func parsePagination(ctx *gin.Context) (models.Pagination, error) {
+	page := models.Pagination{}
+
+	if p := ctx.Query("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return models.Pagination{}, err
+		}
+		page.Page = n
+	}
+
+	if p := ctx.Query("page_size"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return models.Pagination{}, err
+		}
+		page.PageSize = n
+	}
+
+	return page, nil
+}
+
 func (c Controller) CreateOrder(ctx *gin.Context) {
 	var order models.Orders
 	err := ctx.ShouldBindJSON(&order)
@@ -25,41 +140,70 @@ func (c Controller) CreateOrder(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
 
 	res, err := c.Service.CreateOrder(order)
 	if err != nil {
-		var appErr models.Error
-		if errors.As(err, &appErr) {
-			ctx.JSON(appErr.StatusCode, gin.H{
-				"error":  appErr.Message,
-				"kind":   appErr.Kind,
-				"status": appErr.StatusCode,
-			})
-		}
-	} else {
-		ctx.JSON(http.StatusCreated, gin.H{
-			"data": res,
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) CreateOrderBatch(ctx *gin.Context) {
+	var batch models.OrderBatchDtoInput
+	if err := ctx.ShouldBindJSON(&batch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.CreateOrderBatch(batch)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) CreateOcoOrder(ctx *gin.Context) {
+	var input models.OrderOcoDtoInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
+		return
+	}
+
+	res, err := c.Service.CreateOcoOrder(input)
+	if err != nil {
+		respondError(ctx, err)
+		return
 	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
 }
 
 func (c Controller) ListOrders(ctx *gin.Context) {
 	res, err := c.Service.ListOrders()
 	if err != nil {
-		var appErr models.Error
-		if errors.As(err, &appErr) {
-			ctx.JSON(appErr.StatusCode, gin.H{
-				"error":  appErr.Message,
-				"kind":   appErr.Kind,
-				"status": appErr.StatusCode,
-			})
-		}
-	} else {
-		ctx.JSON(http.StatusOK, gin.H{
-			"data": res,
-		})
+		respondError(ctx, err)
+		return
 	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
 }
 
 func (c Controller) UpdateStatusOrder(ctx *gin.Context) {
@@ -70,23 +214,40 @@ func (c Controller) UpdateStatusOrder(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
+		return
 	}
 
 	res, err := c.Service.UpdateStatusOrder(status, orderId)
 	if err != nil {
-		var appErr models.Error
-		if errors.As(err, &appErr) {
-			ctx.JSON(appErr.StatusCode, gin.H{
-				"error":  appErr.Message,
-				"kind":   appErr.Kind,
-				"status": appErr.StatusCode,
-			})
-		}
-	} else {
-		ctx.JSON(http.StatusOK, gin.H{
-			"data": res,
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) AmendOrder(ctx *gin.Context) {
+	orderId := ctx.Param("orderId")
+
+	var amendment models.OrderAmendDtoInput
+	if err := ctx.ShouldBindJSON(&amendment); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
+		return
 	}
+
+	res, err := c.Service.AmendOrder(orderId, amendment)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
 }
 
 func (c Controller) GetClientById(ctx *gin.Context) {
@@ -94,17 +255,326 @@ func (c Controller) GetClientById(ctx *gin.Context) {
 
 	res, err := c.Service.GetClientById(id)
 	if err != nil {
-		var appErr models.Error
-		if errors.As(err, &appErr) {
-			ctx.JSON(appErr.StatusCode, gin.H{
-				"error":  appErr.Message,
-				"kind":   appErr.Kind,
-				"status": appErr.StatusCode,
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) CreateClient(ctx *gin.Context) {
+	var client models.ClientDtoInput
+	if err := ctx.ShouldBindJSON(&client); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.CreateClient(client)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListClients(ctx *gin.Context) {
+	page, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.ListClients(page)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) UpdateClient(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var update models.ClientUpdateDtoInput
+	if err := ctx.ShouldBindJSON(&update); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.UpdateClient(id, update)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) Deposit(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var movement models.LedgerMovementDtoInput
+	if err := ctx.ShouldBindJSON(&movement); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.Deposit(id, movement)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) RequestWithdrawal(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var movement models.LedgerMovementDtoInput
+	if err := ctx.ShouldBindJSON(&movement); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.RequestWithdrawal(id, movement)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListWithdrawals(ctx *gin.Context) {
+	page, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.ListWithdrawals(ctx.Query("status"), page)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ApproveWithdrawal(ctx *gin.Context) {
+	c.reviewWithdrawal(ctx, c.Service.ApproveWithdrawal)
+}
+
+func (c Controller) RejectWithdrawal(ctx *gin.Context) {
+	c.reviewWithdrawal(ctx, c.Service.RejectWithdrawal)
+}
+
+func (c Controller) SendWithdrawal(ctx *gin.Context) {
+	c.reviewWithdrawal(ctx, c.Service.SendWithdrawal)
+}
+
+// reviewWithdrawal binds the operator acting on the withdrawal and applies the review.
+func (c Controller) reviewWithdrawal(ctx *gin.Context, review func(string, models.WithdrawalActionDtoInput) (models.Withdrawal, error)) {
+	id := ctx.Param("withdrawalId")
+
+	var action models.WithdrawalActionDtoInput
+	if err := ctx.ShouldBindJSON(&action); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := review(id, action)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListClientLedger(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	page, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.ListClientLedger(id, page)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ReconcileLedger(ctx *gin.Context) {
+	res, err := c.Service.ReconcileLedger()
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListTrades(ctx *gin.Context) {
+	filter, err := parseTradeFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.ListTrades(filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListClientTrades(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	filter, err := parseTradeFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.ListClientTrades(id, filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) CancelClientOrders(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	filter, err := parseOrderCancelFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	res, err := c.Service.CancelClientOrders(id, filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) GetBook(ctx *gin.Context) {
+	depth := models.DefaultBookDepth
+	if d := ctx.Query("depth"); d != "" {
+		var err error
+		depth, err = strconv.Atoi(d)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
 			})
+			return
 		}
-	} else {
-		ctx.JSON(http.StatusOK, gin.H{
-			"data": res,
+	}
+
+	res, err := c.Service.GetBook(ctx.Query("market"), depth)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) CreateMarket(ctx *gin.Context) {
+	var market models.MarketDtoInput
+	if err := ctx.ShouldBindJSON(&market); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
+		return
 	}
+
+	res, err := c.Service.CreateMarket(market)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": res,
+	})
+}
+
+func (c Controller) ListMarkets(ctx *gin.Context) {
+	res, err := c.Service.ListMarkets()
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": res,
+	})
 }
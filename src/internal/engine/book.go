@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"MB-test/src/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type entry struct {
+	order    models.Orders
+	sequence uint64
+}
+
+// OrderBook keeps the resting limit orders in price-time priority. Bids are sorted
+// from the highest to the lowest price, asks from the lowest to the highest, and
+// within a price level the order that arrived first comes first. The stop orders of
+// the market wait apart, in the order they arrived, until they are triggered.
+//
+// OrderBook is not safe for concurrent use, it is owned by the Engine goroutine.
+type OrderBook struct {
+	bids     []entry
+	asks     []entry
+	stops    []models.Orders
+	sequence uint64
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{}
+}
+
+func (b *OrderBook) side(typeOrder int) *[]entry {
+	if typeOrder == models.BUY {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// before reports whether a has priority over b on the given side.
+func before(typeOrder int, a, b entry) bool {
+	if !a.order.Price.Equal(b.order.Price) {
+		if typeOrder == models.BUY {
+			return a.order.Price.GreaterThan(b.order.Price)
+		}
+		return a.order.Price.LessThan(b.order.Price)
+	}
+	return a.sequence < b.sequence
+}
+
+// Add places the order in the book behind every order already resting at its price.
+func (b *OrderBook) Add(order models.Orders) {
+	b.sequence++
+	e := entry{order: order, sequence: b.sequence}
+
+	side := b.side(order.TypeOrder)
+	i := sort.Search(len(*side), func(i int) bool {
+		return before(order.TypeOrder, e, (*side)[i])
+	})
+
+	*side = append(*side, entry{})
+	copy((*side)[i+1:], (*side)[i:])
+	(*side)[i] = e
+}
+
+func (b *OrderBook) find(id uuid.UUID) (*[]entry, int) {
+	for _, side := range []*[]entry{&b.bids, &b.asks} {
+		for i, e := range *side {
+			if e.order.Id == id {
+				return side, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// Get returns the resting order with the given id.
+func (b *OrderBook) Get(id uuid.UUID) (models.Orders, bool) {
+	side, i := b.find(id)
+	if side == nil {
+		return models.Orders{}, false
+	}
+	return (*side)[i].order, true
+}
+
+// Remove takes the order out of the book, returning it when it was resting or
+// waiting for its trigger.
+func (b *OrderBook) Remove(id uuid.UUID) (models.Orders, bool) {
+	side, i := b.find(id)
+	if side == nil {
+		return b.removeStop(id)
+	}
+
+	order := (*side)[i].order
+	*side = append((*side)[:i], (*side)[i+1:]...)
+	return order, true
+}
+
+// AddStop keeps a stop order out of the book until it is triggered.
+func (b *OrderBook) AddStop(order models.Orders) {
+	b.stops = append(b.stops, order)
+}
+
+func (b *OrderBook) removeStop(id uuid.UUID) (models.Orders, bool) {
+	for i, order := range b.stops {
+		if order.Id == id {
+			b.stops = append(b.stops[:i], b.stops[i+1:]...)
+			return order, true
+		}
+	}
+	return models.Orders{}, false
+}
+
+// NextTriggered takes out of the book the oldest stop order triggered by the last
+// execution price.
+func (b *OrderBook) NextTriggered(lastPrice decimal.Decimal) (models.Orders, bool) {
+	for _, order := range b.stops {
+		if order.StopTriggered(lastPrice) {
+			return b.removeStop(order.Id)
+		}
+	}
+	return models.Orders{}, false
+}
+
+// Stops returns the stop orders waiting for their trigger, oldest first.
+func (b *OrderBook) Stops() []models.Orders {
+	return append([]models.Orders{}, b.stops...)
+}
+
+// Update replaces a resting order keeping its priority. The price must not change.
+func (b *OrderBook) Update(order models.Orders) bool {
+	side, i := b.find(order.Id)
+	if side == nil {
+		return false
+	}
+	(*side)[i].order = order
+	return true
+}
+
+// Best returns the order with the highest priority on the given side.
+func (b *OrderBook) Best(typeOrder int) (models.Orders, bool) {
+	side := *b.side(typeOrder)
+	if len(side) == 0 {
+		return models.Orders{}, false
+	}
+	return side[0].order, true
+}
+
+// Crossing returns, in priority order, the resting orders the taker can be matched against.
+func (b *OrderBook) Crossing(taker models.Orders) []models.Orders {
+	opposite := models.SELL
+	if taker.TypeOrder == models.SELL {
+		opposite = models.BUY
+	}
+
+	orders := []models.Orders{}
+	for _, e := range *b.side(opposite) {
+		if !taker.Crosses(e.order) {
+			break
+		}
+		orders = append(orders, e.order)
+	}
+	return orders
+}
+
+// Orders returns every resting order of the given side in priority order.
+func (b *OrderBook) Orders(typeOrder int) []models.Orders {
+	side := *b.side(typeOrder)
+	orders := make([]models.Orders, 0, len(side))
+	for _, e := range side {
+		orders = append(orders, e.order)
+	}
+	return orders
+}
+
+// Levels aggregates the visible quantity of one side by price, best prices first,
+// up to depth levels, so only the slice shown by iceberg orders is counted. Orders
+// already expired at now are left out.
+func (b *OrderBook) Levels(typeOrder int, depth int, now time.Time) []models.BookLevel {
+	levels := []models.BookLevel{}
+	for _, e := range *b.side(typeOrder) {
+		if e.order.IsExpired(now) {
+			continue
+		}
+
+		last := len(levels) - 1
+		if last >= 0 && levels[last].Price.Equal(e.order.Price) {
+			levels[last].Quantity = levels[last].Quantity.Add(e.order.VisibleQuantity())
+			levels[last].Orders++
+			continue
+		}
+
+		if len(levels) == depth {
+			break
+		}
+		levels = append(levels, models.BookLevel{
+			Price:    e.order.Price,
+			Quantity: e.order.VisibleQuantity(),
+			Orders:   1,
+		})
+	}
+	return levels
+}
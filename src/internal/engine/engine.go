@@ -0,0 +1,665 @@
+package engine
+
+import (
+	"MB-test/src/internal/contracts"
+	"MB-test/src/models"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxMatchAttempts limits how many times an order is matched again after conflicting
+// with a concurrent change in the database.
+const maxMatchAttempts = 3
+
+// Engine owns one order book per market. Every command that reads or changes a book
+// runs in a single goroutine, one at a time and in the order it arrived, so two requests
+// can never match the same resting order. The results are persisted through the
+// repository before the book is changed. The price of the last execution of each
+// market triggers its stop orders.
+type Engine struct {
+	Repo contracts.OperationsRepositoryHandle
+
+	books      map[string]*OrderBook
+	lastPrices map[string]decimal.Decimal
+	commands   chan func()
+	done       chan struct{}
+	stop       sync.Once
+	wg         sync.WaitGroup
+}
+
+func NewEngine(repo contracts.OperationsRepositoryHandle) *Engine {
+	return &Engine{
+		Repo:       repo,
+		books:      map[string]*OrderBook{},
+		lastPrices: map[string]decimal.Decimal{},
+		commands:   make(chan func()),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start rebuilds the book from the orders resting in the database, triggers the stop
+// orders the last trade of their market already reached and starts the goroutine that
+// runs the commands, until Stop is called.
+func (e *Engine) Start() error {
+	if err := e.load(); err != nil {
+		return err
+	}
+
+	markets := []string{}
+	for market := range e.books {
+		markets = append(markets, market)
+	}
+	for _, market := range markets {
+		e.triggerStops(market)
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		for {
+			select {
+			case <-e.done:
+				return
+			case command := <-e.commands:
+				command()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop ends the engine after the running command finishes. Commands sent afterwards
+// fail with ErrorEngineStopped.
+func (e *Engine) Stop() {
+	e.stop.Do(func() { close(e.done) })
+	e.wg.Wait()
+}
+
+// do runs fn in the engine goroutine and waits for it to finish.
+func (e *Engine) do(fn func()) error {
+	finished := make(chan struct{})
+
+	select {
+	case <-e.done:
+		return models.ErrorEngineStopped
+	case e.commands <- func() { defer close(finished); fn() }:
+	}
+
+	<-finished
+	return nil
+}
+
+// load replaces the books with the orders resting in the database and the stop
+// orders waiting for their trigger, and the last prices with those of the last trade
+// of each market.
+func (e *Engine) load() error {
+	orders, err := e.Repo.ListRestingOrders()
+	if err != nil {
+		return err
+	}
+
+	stops, err := e.Repo.ListPendingStopOrders()
+	if err != nil {
+		return err
+	}
+
+	lastPrices, err := e.Repo.ListLastPrices()
+	if err != nil {
+		return err
+	}
+
+	// The book queues the orders in the order they are added, so they are added in time
+	// priority whatever order the repository returned them in.
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].QueuedAt().Before(orders[j].QueuedAt())
+	})
+
+	e.books = map[string]*OrderBook{}
+	e.lastPrices = lastPrices
+	for _, order := range orders {
+		e.book(order.Market).Add(order)
+	}
+	for _, order := range stops {
+		e.book(order.Market).AddStop(order)
+	}
+
+	return nil
+}
+
+// book returns the book of the market, opening it on its first order.
+func (e *Engine) book(market string) *OrderBook {
+	book, ok := e.books[market]
+	if !ok {
+		book = NewOrderBook()
+		e.books[market] = book
+	}
+	return book
+}
+
+// Submit stores a new order and, when it is OPEN, matches it against the book. Market
+// orders take the worst price they need to reach as protection price, and market and
+// FOK orders are rejected before being stored when the book cannot fill them. What a
+// market, IOC or FOK order could not fill is cancelled; the remainder of any other
+// order rests in the book. Stop orders are stored PENDING_TRIGGER and wait for their
+// trigger out of the book. It returns the order as it is after matching.
+func (e *Engine) Submit(order models.Orders) (models.Orders, error) {
+	var (
+		result models.Orders
+		err    error
+	)
+
+	if doErr := e.do(func() { result, err = e.submit(order) }); doErr != nil {
+		return models.Orders{}, doErr
+	}
+
+	return result, err
+}
+
+func (e *Engine) submit(order models.Orders) (models.Orders, error) {
+	if order.Status == models.OPEN && (order.OrderKind == models.MARKET || order.TimeInForce == models.FOK) {
+		price, err := e.fillPrice(order)
+		if err != nil {
+			if order.OrderKind == models.LIMIT {
+				return models.Orders{}, models.ErrorFillOrKillNotFillable
+			}
+			return models.Orders{}, err
+		}
+
+		if order.OrderKind == models.MARKET {
+			order.Price = price
+		}
+	}
+
+	// The notional of a market order is only known once it is priced against the book.
+	if order.NotionalLimit.IsPositive() && order.Notional().GreaterThan(order.NotionalLimit) {
+		return models.Orders{}, models.ErrorOrderNotionalLimit
+	}
+
+	order.HeldAmount = order.HoldFor(order.Quantity)
+	if order.IsStop() {
+		order.Status = models.PENDING_TRIGGER
+	}
+
+	if _, err := e.Repo.CreateOrder(order); err != nil {
+		return models.Orders{}, err
+	}
+
+	if order.Status == models.PENDING_TRIGGER {
+		return e.wait(order)
+	}
+
+	if order.Status != models.OPEN {
+		return order, nil
+	}
+
+	return e.place(order)
+}
+
+// SubmitBatch stores all the orders at once, so either every one of them is created
+// or none is, and then matches the OPEN ones in the order they were sent. When the
+// batch is not created, it returns the index of the order that failed, or -1.
+// Market and FOK orders are not accepted, their fill depends on the book at the time
+// each one is submitted.
+func (e *Engine) SubmitBatch(orders []models.Orders) ([]models.Orders, int, error) {
+	var (
+		result []models.Orders
+		failed int
+		err    error
+	)
+
+	if doErr := e.do(func() { result, failed, err = e.submitBatch(orders) }); doErr != nil {
+		return []models.Orders{}, -1, doErr
+	}
+
+	return result, failed, err
+}
+
+func (e *Engine) submitBatch(orders []models.Orders) ([]models.Orders, int, error) {
+	placed := make([]models.Orders, len(orders))
+	for i, order := range orders {
+		if order.OrderKind == models.MARKET || order.TimeInForce == models.FOK {
+			return []models.Orders{}, i, models.ErrorInvalidAtomicBatchOrder
+		}
+
+		order.HeldAmount = order.HoldFor(order.Quantity)
+		if order.IsStop() {
+			order.Status = models.PENDING_TRIGGER
+		}
+		placed[i] = order
+	}
+
+	if failed, err := e.Repo.CreateOrders(placed); err != nil {
+		return []models.Orders{}, failed, err
+	}
+
+	// The repository already cancelled the orders whose OCO pair was filled or left
+	// the book while the batch was placed.
+	unlinked := map[uuid.UUID]bool{}
+
+	for i, order := range placed {
+		if order.LinkedOrderId != nil && unlinked[*order.LinkedOrderId] {
+			placed[i] = order.Cancelled(models.CloseReasonOco)
+			continue
+		}
+
+		if order.Status == models.PENDING_TRIGGER {
+			waiting, err := e.wait(order)
+			if err != nil {
+				log.Printf("Error triggering stop order %s of the batch: %v\n", order.Id, err)
+			}
+			placed[i] = waiting
+			unlinked[order.Id] = waiting.FilledQuantity.IsPositive() || !waiting.IsResting()
+			continue
+		}
+
+		if order.Status != models.OPEN {
+			continue
+		}
+
+		matched, err := e.place(order)
+		if err != nil {
+			log.Printf("Error placing order %s of the batch: %v\n", order.Id, err)
+		}
+		placed[i] = matched
+		unlinked[order.Id] = matched.FilledQuantity.IsPositive() || !matched.IsResting()
+	}
+
+	return placed, -1, nil
+}
+
+// place matches an order that entered the book, decides what happens to its remainder
+// and then triggers the stop orders its executions reached.
+func (e *Engine) place(order models.Orders) (models.Orders, error) {
+	placed, err := e.execute(order)
+	e.triggerStops(order.Market)
+	return placed, err
+}
+
+// execute matches the order and decides what happens to its remainder. When an
+// execution conflicts with a concurrent change in the database, the book is reloaded
+// and the order, as persisted, is matched again.
+func (e *Engine) execute(order models.Orders) (models.Orders, error) {
+	matched, err := e.match(order)
+	for attempt := 1; errors.Is(err, models.ErrorOrderConflict) && attempt < maxMatchAttempts; attempt++ {
+		matched, err = e.rematch(matched)
+	}
+
+	if err != nil {
+		log.Printf("Error matching order %s: %v\n", order.Id, err)
+		if err := e.load(); err != nil {
+			log.Printf("Error reloading the order book: %v\n", err)
+		}
+	}
+
+	// Market, IOC and FOK orders never rest in the book: whatever could not be filled is cancelled.
+	if !matched.CanRest() && matched.IsResting() && (err != nil || matched.RemainingQuantity().IsPositive()) {
+		e.book(matched.Market).Remove(matched.Id)
+
+		if _, err := e.Repo.UpdateStatusOrder(models.CANCEL, matched.Id.String(), models.CloseReasonUnfilled); err != nil {
+			return matched, err
+		}
+		e.removeLinked(matched)
+
+		return matched.Cancelled(models.CloseReasonUnfilled), nil
+	}
+
+	// After a failure the reloaded book already has the order as it was persisted.
+	if err == nil && matched.IsResting() {
+		e.book(matched.Market).Add(matched)
+	}
+
+	return matched, nil
+}
+
+// rematch reloads the book from the database and matches the order again from the
+// state it was persisted in.
+func (e *Engine) rematch(order models.Orders) (models.Orders, error) {
+	if err := e.load(); err != nil {
+		return order, err
+	}
+
+	persisted, err := e.Repo.GetOrderById(order.Id.String())
+	if err != nil {
+		return order, err
+	}
+
+	e.book(persisted.Market).Remove(persisted.Id)
+	if !persisted.IsResting() {
+		return persisted, nil
+	}
+
+	return e.match(persisted)
+}
+
+// match sweeps the opposite side of the book of the order's market in price-time
+// priority, filling the order against as many resting orders as needed. Resting orders
+// found expired are cancelled on the way. A resting iceberg order is only filled up to
+// the slice it shows; when the slice is used up, the next one goes to the back of its
+// price level. It returns the order as it is after the fills.
+func (e *Engine) match(order models.Orders) (models.Orders, error) {
+	book := e.book(order.Market)
+
+	opposite := models.SELL
+	if order.TypeOrder == models.SELL {
+		opposite = models.BUY
+	}
+
+	for order.RemainingQuantity().IsPositive() {
+		resting, ok := book.Best(opposite)
+		if !ok || !order.Crosses(resting) {
+			break
+		}
+
+		if resting.IsExpired(time.Now()) {
+			if _, err := e.Repo.UpdateStatusOrder(models.CANCEL, resting.Id.String(), models.CloseReasonExpired); err != nil {
+				return order, err
+			}
+			book.Remove(resting.Id)
+			e.removeLinked(resting)
+			continue
+		}
+
+		quantity := decimal.Min(order.RemainingQuantity(), resting.VisibleQuantity())
+
+		var err error
+		if order.TypeOrder == models.SELL {
+			err = e.Repo.MakeTransactionSell(resting, order, quantity)
+		} else {
+			err = e.Repo.MakeTransactionBuy(order, resting, quantity)
+		}
+		if err != nil {
+			return order, err
+		}
+
+		replenished := resting.Replenishes(quantity)
+
+		order = order.AfterFill(quantity)
+		resting = resting.AfterMakerFill(quantity)
+		e.lastPrices[order.Market] = resting.Price
+		e.removeLinked(order)
+		e.removeLinked(resting)
+
+		switch {
+		case resting.Status == models.DONE:
+			book.Remove(resting.Id)
+		case replenished:
+			now := time.Now()
+			resting.PriorityAt = &now
+			book.Remove(resting.Id)
+			book.Add(resting)
+		default:
+			book.Update(resting)
+		}
+	}
+
+	return order, nil
+}
+
+// wait keeps a stop order out of the book until it is triggered, triggering it at
+// once when the last execution price of its market already reached the stop price.
+func (e *Engine) wait(order models.Orders) (models.Orders, error) {
+	lastPrice, ok := e.lastPrices[order.Market]
+	if !ok || !order.StopTriggered(lastPrice) {
+		e.book(order.Market).AddStop(order)
+		return order, nil
+	}
+
+	triggered, err := e.trigger(order)
+	e.triggerStops(order.Market)
+	return triggered, err
+}
+
+// triggerStops triggers, one at a time and oldest first, the stop orders of the
+// market reached by its last execution price. The executions of a triggered order
+// may move the price and trigger the next ones. A stop order whose trigger fails is
+// logged and skipped, so the rest are still triggered; once they are done the book is
+// reloaded and the failed ones are back waiting in the state they were persisted in.
+func (e *Engine) triggerStops(market string) {
+	failed := false
+	defer func() {
+		if !failed {
+			return
+		}
+		if err := e.load(); err != nil {
+			log.Printf("Error reloading the order book: %v\n", err)
+		}
+	}()
+
+	for {
+		lastPrice, ok := e.lastPrices[market]
+		if !ok {
+			return
+		}
+
+		stop, ok := e.book(market).NextTriggered(lastPrice)
+		if !ok {
+			return
+		}
+
+		if _, err := e.trigger(stop); err != nil {
+			log.Printf("Error triggering stop order %s: %v\n", stop.Id, err)
+			failed = true
+		}
+	}
+}
+
+// trigger converts the stop order into the limit or market order it places, with
+// a new time priority, and matches it. A stop-market order takes the worst price it
+// needs to reach as protection price and holds for it; it is cancelled when the book
+// cannot fill it, its notional at that price is above the limit of its owner's score
+// band or its owner cannot hold the difference.
+func (e *Engine) trigger(stop models.Orders) (models.Orders, error) {
+	order := stop.Triggered()
+	now := time.Now()
+	order.PriorityAt = &now
+
+	if order.OrderKind == models.MARKET {
+		price, err := e.fillPrice(order)
+		if err != nil {
+			return e.cancelStop(order, models.CloseReasonUnfilled)
+		}
+		order.Price = price
+
+		if order.NotionalLimit.IsPositive() && order.Notional().GreaterThan(order.NotionalLimit) {
+			return e.cancelStop(order, models.CloseReasonOverLimit)
+		}
+		order.HeldAmount = order.HoldFor(order.Quantity)
+	}
+
+	if _, err := e.Repo.TriggerOrder(order); err != nil {
+		if errors.Is(err, models.ErrorInsufficientBalance) {
+			return e.cancelStop(order, models.CloseReasonNoBalance)
+		}
+		return stop, err
+	}
+
+	return e.execute(order)
+}
+
+// cancelStop cancels a stop order that could not be placed once triggered.
+func (e *Engine) cancelStop(order models.Orders, reason string) (models.Orders, error) {
+	if _, err := e.Repo.UpdateStatusOrder(models.CANCEL, order.Id.String(), reason); err != nil {
+		return order, err
+	}
+	e.removeLinked(order)
+
+	return order.Cancelled(reason), nil
+}
+
+// removeLinked takes out of the book the other order of the OCO pair of the given
+// one, after the repository cancelled it.
+func (e *Engine) removeLinked(order models.Orders) {
+	if order.LinkedOrderId != nil {
+		e.book(order.Market).Remove(*order.LinkedOrderId)
+	}
+}
+
+// fillPrice walks the book the way the order would and returns the worst price it
+// needs to reach to be completely filled, or ErrorInsufficientLiquidity.
+func (e *Engine) fillPrice(order models.Orders) (decimal.Decimal, error) {
+	now := time.Now()
+	remaining := order.Quantity
+
+	for _, resting := range e.book(order.Market).Crossing(order) {
+		if resting.IsExpired(now) {
+			continue
+		}
+
+		remaining = remaining.Sub(resting.RemainingQuantity())
+		if !remaining.IsPositive() {
+			return resting.Price, nil
+		}
+	}
+
+	return decimal.Zero, models.ErrorInsufficientLiquidity
+}
+
+// UpdateStatus moves the order to the given status following the status transition
+// rules, recording the reason when it leaves the book. An order leaving the book is
+// removed from it, and a WAITING order that is opened is matched like a new one.
+func (e *Engine) UpdateStatus(orderId string, status int, reason string) (models.Orders, error) {
+	var (
+		result models.Orders
+		err    error
+	)
+
+	if doErr := e.do(func() { result, err = e.updateStatus(orderId, status, reason) }); doErr != nil {
+		return models.Orders{}, doErr
+	}
+
+	return result, err
+}
+
+func (e *Engine) updateStatus(orderId string, status int, reason string) (models.Orders, error) {
+	order, err := e.Repo.GetOrderById(orderId)
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	newStatus, inEffect, err := order.StatusTransition(status)
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	if inEffect {
+		return order, nil
+	}
+
+	updated, err := e.Repo.UpdateStatusOrder(newStatus, orderId, reason)
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	e.book(order.Market).Remove(order.Id)
+	if order.Reopens(newStatus) {
+		order.PriorityAt = updated.PriorityAt
+	}
+	order.Status = newStatus
+
+	if newStatus == models.DONE || newStatus == models.CANCEL {
+		e.removeLinked(order)
+		order.CloseReason = reason
+		order.HeldAmount = decimal.Zero
+		return order, nil
+	}
+
+	if newStatus == models.OPEN || newStatus == models.PARTIALLY_FILLED {
+		return e.place(order)
+	}
+
+	return order, nil
+}
+
+// Amend changes the price and quantity of a resting order. An order that keeps its
+// priority is only updated in the book; otherwise it goes to the back of its price
+// level and, when OPEN, is matched again like a new order.
+func (e *Engine) Amend(orderId string, price, quantity decimal.Decimal) (models.Orders, error) {
+	var (
+		result models.Orders
+		err    error
+	)
+
+	if doErr := e.do(func() { result, err = e.amend(orderId, price, quantity) }); doErr != nil {
+		return models.Orders{}, doErr
+	}
+
+	return result, err
+}
+
+func (e *Engine) amend(orderId string, price, quantity decimal.Decimal) (models.Orders, error) {
+	order, err := e.Repo.GetOrderById(orderId)
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	amended, keepsPriority, err := order.Amend(price, quantity)
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	if !keepsPriority {
+		now := time.Now()
+		amended.PriorityAt = &now
+	}
+
+	if _, err := e.Repo.AmendOrder(amended); err != nil {
+		return models.Orders{}, err
+	}
+
+	if amended.Status == models.WAITING {
+		return amended, nil
+	}
+
+	if keepsPriority {
+		e.book(amended.Market).Update(amended)
+		return amended, nil
+	}
+
+	e.book(amended.Market).Remove(amended.Id)
+	return e.place(amended)
+}
+
+// CancelClientOrders cancels at once the orders of the client matching the filter and
+// takes them out of the book.
+func (e *Engine) CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error) {
+	var (
+		result []models.Orders
+		err    error
+	)
+
+	doErr := e.do(func() {
+		result, err = e.Repo.CancelClientOrders(clientId, filter, reason)
+		for _, order := range result {
+			e.book(order.Market).Remove(order.Id)
+		}
+	})
+	if doErr != nil {
+		return []models.Orders{}, doErr
+	}
+
+	return result, err
+}
+
+// Book returns the bid and ask price levels of the book of the market, best prices first.
+func (e *Engine) Book(market string, depth int) (models.BookDtoOutput, error) {
+	var book models.BookDtoOutput
+
+	err := e.do(func() {
+		now := time.Now()
+		book = models.BookDtoOutput{
+			Market: market,
+			Bids:   e.book(market).Levels(models.BUY, depth, now),
+			Asks:   e.book(market).Levels(models.SELL, depth, now),
+		}
+	})
+
+	return book, err
+}
@@ -0,0 +1,1246 @@
+package engine_test
+
+import (
+	"MB-test/src/internal/engine"
+	"MB-test/src/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) ListOrders() ([]models.Orders, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CreateOrder(order models.Orders) (models.Orders, error) {
+	args := m.Called(order)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CreateOrders(orders []models.Orders) (int, error) {
+	args := m.Called(orders)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateClient(client models.Client) (models.Client, error) {
+	args := m.Called(client)
+	return args.Get(0).(models.Client), args.Error(1)
+}
+
+func (m *MockRepo) ListClients(page models.Pagination) ([]models.Client, int64, error) {
+	args := m.Called(page)
+	return args.Get(0).([]models.Client), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) UpdateClientScore(id string, score int) (models.Client, error) {
+	args := m.Called(id, score)
+	return args.Get(0).(models.Client), args.Error(1)
+}
+
+func (m *MockRepo) Deposit(clientId string, asset string, amount decimal.Decimal) (models.Journal, error) {
+	args := m.Called(clientId, asset, amount)
+	return args.Get(0).(models.Journal), args.Error(1)
+}
+
+func (m *MockRepo) RequestWithdrawal(clientId string, asset string, amount decimal.Decimal) (models.Withdrawal, error) {
+	args := m.Called(clientId, asset, amount)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) GetWithdrawalById(id string) (models.Withdrawal, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) ListWithdrawals(statuses []string, page models.Pagination) ([]models.Withdrawal, int64, error) {
+	args := m.Called(statuses, page)
+	return args.Get(0).([]models.Withdrawal), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) UpdateWithdrawal(previous, updated models.Withdrawal) (models.Withdrawal, error) {
+	args := m.Called(previous, updated)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) ListLedgerEntries(clientId string, page models.Pagination) ([]models.LedgerEntry, int64, error) {
+	args := m.Called(clientId, page)
+	return args.Get(0).([]models.LedgerEntry), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) ReconcileLedger() ([]models.LedgerMismatch, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LedgerMismatch), args.Error(1)
+}
+
+func (m *MockRepo) GetClientById(id string) (models.Client, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Client), args.Error(1)
+}
+
+func (m *MockRepo) ListRestingOrders() ([]models.Orders, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListPendingStopOrders() ([]models.Orders, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListLastPrices() (map[string]decimal.Decimal, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]decimal.Decimal), args.Error(1)
+}
+
+func (m *MockRepo) TriggerOrder(order models.Orders) (models.Orders, error) {
+	args := m.Called(order)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) MakeTransactionSell(buy models.Orders, sell models.Orders, quantity decimal.Decimal) error {
+	args := m.Called(buy, sell, quantity)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MakeTransactionBuy(buy models.Orders, sell models.Orders, quantity decimal.Decimal) error {
+	args := m.Called(buy, sell, quantity)
+	return args.Error(0)
+}
+
+func (m *MockRepo) AmendOrder(order models.Orders) (models.Orders, error) {
+	args := m.Called(order)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error) {
+	args := m.Called(clientId, filter, reason)
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CountOpenOrders(clientId string) (int64, error) {
+	args := m.Called(clientId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) GetOrderById(id string) (models.Orders, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListTrades(filter models.TradeFilter) ([]models.Trade, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Trade), args.Error(1)
+}
+
+func (m *MockRepo) UpdateStatusOrder(status int, orderId string, reason string) (models.Orders, error) {
+	args := m.Called(status, orderId, reason)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListOrdersToExpire(now time.Time, staleBefore *time.Time) ([]models.Orders, error) {
+	args := m.Called(now, staleBefore)
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CreateMarket(market models.Market) (models.Market, error) {
+	args := m.Called(market)
+	return args.Get(0).(models.Market), args.Error(1)
+}
+
+func (m *MockRepo) GetMarket(symbol string) (models.Market, error) {
+	args := m.Called(symbol)
+	return args.Get(0).(models.Market), args.Error(1)
+}
+
+func (m *MockRepo) ListMarkets() ([]models.Market, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Market), args.Error(1)
+}
+
+// decimalEqual matches a decimal argument by value, regardless of its internal exponent.
+func decimalEqual(value string) interface{} {
+	expected := decimal.RequireFromString(value)
+	return mock.MatchedBy(func(d decimal.Decimal) bool {
+		return d.Equal(expected)
+	})
+}
+
+// sameOrder matches an order argument by id, whatever its fills.
+func sameOrder(order models.Orders) interface{} {
+	return mock.MatchedBy(func(o models.Orders) bool {
+		return o.Id == order.Id
+	})
+}
+
+// startEngine starts an engine whose book is rebuilt from the given resting orders.
+func startEngine(t *testing.T, repo *MockRepo, resting ...models.Orders) *engine.Engine {
+	repo.On("ListRestingOrders").Return(resting, nil)
+	repo.On("ListPendingStopOrders").Return([]models.Orders{}, nil)
+	repo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+
+	eng := engine.NewEngine(repo)
+	assert.NoError(t, eng.Start())
+	t.Cleanup(eng.Stop)
+
+	return eng
+}
+
+func limitOrder(typeOrder int, quantity, price string) models.Orders {
+	return models.Orders{
+		Id:           uuid.New(),
+		OwnerOrderId: uuid.New(),
+		Market:       models.DefaultMarket,
+		TypeOrder:    typeOrder,
+		OrderKind:    models.LIMIT,
+		TimeInForce:  models.GTC,
+		Status:       models.OPEN,
+		Quantity:     decimal.RequireFromString(quantity),
+		Price:        decimal.RequireFromString(price),
+	}
+}
+
+func TestOrderBook(t *testing.T) {
+	t.Run("Must keep the best price first and the oldest order first within a price", func(t *testing.T) {
+		book := engine.NewOrderBook()
+
+		first := limitOrder(models.BUY, "1", "100")
+		best := limitOrder(models.BUY, "1", "101")
+		second := limitOrder(models.BUY, "1", "100")
+		ask := limitOrder(models.SELL, "1", "102")
+		bestAsk := limitOrder(models.SELL, "1", "101.5")
+
+		for _, o := range []models.Orders{first, best, second, ask, bestAsk} {
+			book.Add(o)
+		}
+
+		assert.Equal(t, []models.Orders{best, first, second}, book.Orders(models.BUY))
+		assert.Equal(t, []models.Orders{bestAsk, ask}, book.Orders(models.SELL))
+	})
+
+	t.Run("Must aggregate the remaining quantity by price level", func(t *testing.T) {
+		book := engine.NewOrderBook()
+
+		partial := limitOrder(models.SELL, "1", "100")
+		partial.FilledQuantity = decimal.RequireFromString("0.25")
+		expiredAt := time.Now().Add(-time.Minute)
+		expired := limitOrder(models.SELL, "3", "100")
+		expired.ExpiresAt = &expiredAt
+
+		book.Add(partial)
+		book.Add(limitOrder(models.SELL, "0.5", "100"))
+		book.Add(expired)
+		book.Add(limitOrder(models.SELL, "2", "105"))
+		book.Add(limitOrder(models.SELL, "1", "110"))
+
+		levels := book.Levels(models.SELL, 2, time.Now())
+
+		assert.Len(t, levels, 2)
+		assert.True(t, levels[0].Price.Equal(decimal.NewFromInt(100)))
+		assert.True(t, levels[0].Quantity.Equal(decimal.RequireFromString("1.25")))
+		assert.Equal(t, 2, levels[0].Orders)
+		assert.True(t, levels[1].Price.Equal(decimal.NewFromInt(105)))
+	})
+}
+
+func TestSubmit(t *testing.T) {
+	t.Run("Must fill a buy order against several smaller sell orders", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		firstSell := limitOrder(models.SELL, "0.5", "95000")
+		secondSell := limitOrder(models.SELL, "2", "100000")
+		eng := startEngine(t, mockRepo, firstSell, secondSell)
+
+		buy := limitOrder(models.BUY, "1", "100000")
+
+		mockRepo.On("CreateOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.HeldAmount.Equal(decimal.NewFromInt(100000))
+		})).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), firstSell, decimalEqual("0.5")).Return(nil).Once()
+		mockRepo.On("MakeTransactionBuy", mock.MatchedBy(func(o models.Orders) bool {
+			return o.FilledQuantity.Equal(decimal.RequireFromString("0.5")) && o.Status == models.PARTIALLY_FILLED
+		}), secondSell, decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, err := eng.Book(models.DefaultMarket, 10)
+		assert.NoError(t, err)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.RequireFromString("1.5")))
+		assert.Empty(t, book.Bids)
+	})
+
+	t.Run("Must leave the remainder resting when the book runs out of counter-orders", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		buy := limitOrder(models.BUY, "0.5", "100000")
+		eng := startEngine(t, mockRepo, buy)
+
+		sell := limitOrder(models.SELL, "1", "100000")
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(sell, nil).Once()
+		mockRepo.On("MakeTransactionSell", buy, sameOrder(sell), decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(sell)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PARTIALLY_FILLED, matched.Status)
+		assert.True(t, matched.RemainingQuantity().Equal(decimal.RequireFromString("0.5")))
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.RequireFromString("0.5")))
+	})
+
+	t.Run("Must stop sweeping the book once the order is filled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		bestBuy := limitOrder(models.BUY, "1", "110000")
+		worseBuy := limitOrder(models.BUY, "1", "105000")
+		eng := startEngine(t, mockRepo, worseBuy, bestBuy)
+
+		sell := limitOrder(models.SELL, "1", "100000")
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(sell, nil).Once()
+		mockRepo.On("MakeTransactionSell", bestBuy, sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+
+		_, err := eng.Submit(sell)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MakeTransactionSell", worseBuy, mock.Anything, mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Bids, 1)
+		assert.True(t, book.Bids[0].Price.Equal(worseBuy.Price))
+	})
+
+	t.Run("Must hold the notional of a waiting purchase order without matching it", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, limitOrder(models.SELL, "3", "1000"))
+
+		order := limitOrder(models.BUY, "3", "1500")
+		order.Status = models.WAITING
+
+		mockRepo.On("CreateOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.HeldAmount.Equal(decimal.NewFromInt(4500))
+		})).Return(order, nil).Once()
+
+		matched, err := eng.Submit(order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WAITING, matched.Status)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MakeTransactionBuy", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Must cancel resting orders found expired while sweeping the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		expiredAt := time.Now().Add(-time.Second)
+		expired := limitOrder(models.SELL, "1", "100")
+		expired.TimeInForce = models.GTD
+		expired.ExpiresAt = &expiredAt
+		eng := startEngine(t, mockRepo, expired)
+
+		buy := limitOrder(models.BUY, "1", "100")
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(buy, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, expired.Id.String(), models.CloseReasonExpired).Return(models.Orders{}, nil).Once()
+
+		matched, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.OPEN, matched.Status)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MakeTransactionBuy", mock.Anything, mock.Anything, mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+		assert.Len(t, book.Bids, 1)
+	})
+
+	t.Run("Must only match orders of the same market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		buy := limitOrder(models.BUY, "1", "100")
+		eng := startEngine(t, mockRepo, buy)
+
+		sell := limitOrder(models.SELL, "1", "100")
+		sell.Market = "ETH-BRL"
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(sell, nil).Once()
+
+		matched, err := eng.Submit(sell)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.OPEN, matched.Status)
+		mockRepo.AssertNotCalled(t, "MakeTransactionSell", mock.Anything, mock.Anything, mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Bids, 1)
+		assert.Empty(t, book.Asks)
+
+		book, _ = eng.Book("ETH-BRL", 10)
+		assert.Equal(t, "ETH-BRL", book.Market)
+		assert.Empty(t, book.Bids)
+		assert.Len(t, book.Asks, 1)
+	})
+
+	t.Run("Must match orders of a USDT-quoted market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "0.5", "60000")
+		sell.Market = "BTC-USDT"
+		eng := startEngine(t, mockRepo, sell)
+
+		buy := limitOrder(models.BUY, "0.5", "60500")
+		buy.Market = "BTC-USDT"
+
+		mockRepo.On("CreateOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.HoldAsset() == models.AssetUSDT && o.HeldAmount.Equal(decimal.NewFromInt(30250))
+		})).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book("BTC-USDT", 10)
+		assert.Empty(t, book.Bids)
+		assert.Empty(t, book.Asks)
+	})
+}
+
+func TestSubmitBatch(t *testing.T) {
+	t.Run("Must create every order and match them in the order they were sent", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		crossing := limitOrder(models.BUY, "1", "100")
+		resting := limitOrder(models.BUY, "1", "90")
+
+		mockRepo.On("CreateOrders", mock.MatchedBy(func(orders []models.Orders) bool {
+			return len(orders) == 2 && orders[0].HeldAmount.Equal(decimal.NewFromInt(100)) && orders[1].HeldAmount.Equal(decimal.NewFromInt(90))
+		})).Return(-1, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(crossing), sell, decimalEqual("1")).Return(nil).Once()
+
+		placed, failed, err := eng.SubmitBatch([]models.Orders{crossing, resting})
+
+		assert.NoError(t, err)
+		assert.Equal(t, -1, failed)
+		assert.Equal(t, models.DONE, placed[0].Status)
+		assert.Equal(t, models.OPEN, placed[1].Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+		assert.Len(t, book.Bids, 1)
+	})
+
+	t.Run("Must not match anything when the batch cannot be created", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		crossing := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrders", mock.Anything).Return(1, models.ErrorInsufficientBalance).Once()
+
+		_, failed, err := eng.SubmitBatch([]models.Orders{crossing, limitOrder(models.BUY, "1", "90")})
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+		assert.Equal(t, 1, failed)
+		mockRepo.AssertNotCalled(t, "MakeTransactionBuy", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSubmitConflict(t *testing.T) {
+	t.Run("Must match again against the reloaded book when an execution conflicts", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		taken := limitOrder(models.SELL, "1", "100")
+		next := limitOrder(models.SELL, "1", "101")
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{taken, next}, nil).Once()
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{next}, nil).Once()
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		buy := limitOrder(models.BUY, "1", "101")
+		persisted := buy
+		persisted.HeldAmount = decimal.NewFromInt(101)
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), taken, decimalEqual("1")).Return(models.ErrorOrderConflict).Once()
+		mockRepo.On("GetOrderById", buy.Id.String()).Return(persisted, nil).Once()
+		mockRepo.On("MakeTransactionBuy", persisted, next, decimalEqual("1")).Return(nil).Once()
+
+		matched, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+		assert.Empty(t, book.Bids)
+	})
+}
+
+func TestSubmitMarketOrder(t *testing.T) {
+	cheapSell := limitOrder(models.SELL, "0.5", "100")
+	expensiveSell := limitOrder(models.SELL, "1", "110")
+
+	t.Run("Must fill a market buy across price levels, holding at the worst price reached", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, cheapSell, expensiveSell)
+
+		order := models.Orders{
+			Id:          uuid.New(),
+			Market:      models.DefaultMarket,
+			TypeOrder:   models.BUY,
+			OrderKind:   models.MARKET,
+			TimeInForce: models.GTC,
+			Status:      models.OPEN,
+			Quantity:    decimal.NewFromInt(1),
+		}
+
+		mockRepo.On("CreateOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Price.Equal(decimal.NewFromInt(110)) && o.HeldAmount.Equal(decimal.NewFromInt(110))
+		})).Return(order, nil).Once()
+		mockRepo.On("MakeTransactionBuy", mock.Anything, cheapSell, decimalEqual("0.5")).Return(nil).Once()
+		mockRepo.On("MakeTransactionBuy", mock.Anything, expensiveSell, decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, matched.Status)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "UpdateStatusOrder", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should reject a market order whose notional at the fill price is above its limit", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, cheapSell, expensiveSell)
+
+		order := models.Orders{
+			Id:            uuid.New(),
+			Market:        models.DefaultMarket,
+			TypeOrder:     models.BUY,
+			OrderKind:     models.MARKET,
+			TimeInForce:   models.GTC,
+			Status:        models.OPEN,
+			Quantity:      decimal.NewFromInt(1),
+			NotionalLimit: decimal.NewFromInt(105),
+		}
+
+		_, err := eng.Submit(order)
+
+		assert.ErrorIs(t, err, models.ErrorOrderNotionalLimit)
+		mockRepo.AssertNotCalled(t, "CreateOrder", mock.Anything)
+	})
+
+	t.Run("Should reject a market order when the book cannot fill it", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, cheapSell, expensiveSell)
+
+		order := models.Orders{
+			Id:          uuid.New(),
+			Market:      models.DefaultMarket,
+			TypeOrder:   models.BUY,
+			OrderKind:   models.MARKET,
+			TimeInForce: models.GTC,
+			Status:      models.OPEN,
+			Quantity:    decimal.NewFromInt(2),
+		}
+
+		_, err := eng.Submit(order)
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientLiquidity)
+		mockRepo.AssertNotCalled(t, "CreateOrder", mock.Anything)
+	})
+
+	t.Run("Must cancel what a market order could not fill when an execution fails", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		buy := limitOrder(models.BUY, "1", "100")
+		eng := startEngine(t, mockRepo, buy)
+
+		order := models.Orders{
+			Id:          uuid.New(),
+			Market:      models.DefaultMarket,
+			TypeOrder:   models.SELL,
+			OrderKind:   models.MARKET,
+			TimeInForce: models.GTC,
+			Status:      models.OPEN,
+			Quantity:    decimal.NewFromInt(1),
+		}
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(order, nil).Once()
+		mockRepo.On("MakeTransactionSell", buy, sameOrder(order), decimalEqual("1")).Return(errors.New("customer with insufficient balance for this transaction")).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, order.Id.String(), models.CloseReasonUnfilled).Return(models.Orders{}, nil).Once()
+
+		matched, err := eng.Submit(order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.CANCEL, matched.Status)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNumberOfCalls(t, "ListRestingOrders", 2)
+	})
+}
+
+func TestSubmitTimeInForce(t *testing.T) {
+	restingSell := limitOrder(models.SELL, "0.4", "1000")
+
+	t.Run("Must cancel the remainder of an IOC order after matching", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, restingSell)
+
+		order := limitOrder(models.BUY, "1", "1000")
+		order.TimeInForce = models.IOC
+
+		mockRepo.On("CreateOrder", mock.Anything).Return(order, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(order), restingSell, decimalEqual("0.4")).Return(nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, order.Id.String(), models.CloseReasonUnfilled).Return(models.Orders{}, nil).Once()
+
+		matched, err := eng.Submit(order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.CANCEL, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+	})
+
+	t.Run("Should reject a FOK order that the book cannot completely fill", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, restingSell)
+
+		order := limitOrder(models.BUY, "1", "1000")
+		order.TimeInForce = models.FOK
+
+		_, err := eng.Submit(order)
+
+		assert.ErrorIs(t, err, models.ErrorFillOrKillNotFillable)
+		mockRepo.AssertNotCalled(t, "CreateOrder", mock.Anything)
+	})
+}
+
+func stopOrder(typeOrder, orderKind int, quantity, stopPrice, price string) models.Orders {
+	order := limitOrder(typeOrder, quantity, price)
+	order.OrderKind = orderKind
+	order.StopPrice = decimal.RequireFromString(stopPrice)
+	return order
+}
+
+func TestSubmitStopOrder(t *testing.T) {
+	t.Run("Must keep a stop order out of the book until it is triggered", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo, limitOrder(models.SELL, "1", "100"))
+
+		stop := stopOrder(models.BUY, models.STOP_LIMIT, "1", "105", "110")
+
+		mockRepo.On("CreateOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Status == models.PENDING_TRIGGER && o.HeldAmount.Equal(decimal.NewFromInt(110))
+		})).Return(stop, nil).Once()
+
+		order, err := eng.Submit(stop)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PENDING_TRIGGER, order.Status)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MakeTransactionBuy", mock.Anything, mock.Anything, mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+		assert.Len(t, book.Asks, 1)
+	})
+
+	t.Run("Must place a stop-limit order once an execution reaches its stop price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		cheapSell := limitOrder(models.SELL, "1", "100")
+		expensiveSell := limitOrder(models.SELL, "1", "110")
+		eng := startEngine(t, mockRepo, cheapSell, expensiveSell)
+
+		stop := stopOrder(models.BUY, models.STOP_LIMIT, "1", "100", "110")
+		mockRepo.On("CreateOrder", sameOrder(stop)).Return(stop, nil).Once()
+
+		_, err := eng.Submit(stop)
+		assert.NoError(t, err)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), cheapSell, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("TriggerOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Id == stop.Id && o.Status == models.OPEN && o.OrderKind == models.LIMIT && o.PriorityAt != nil
+		})).Return(stop, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(stop), expensiveSell, decimalEqual("1")).Return(nil).Once()
+
+		_, err = eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+		assert.Empty(t, book.Bids)
+	})
+
+	t.Run("Must trigger at once a stop-market order whose stop price was already reached", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		bestBuy := limitOrder(models.BUY, "1", "90")
+		worseBuy := limitOrder(models.BUY, "1", "80")
+		eng := startEngine(t, mockRepo, sell, bestBuy, worseBuy)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+
+		_, err := eng.Submit(buy)
+		assert.NoError(t, err)
+
+		stop := stopOrder(models.SELL, models.STOP_MARKET, "2", "100", "0")
+		mockRepo.On("CreateOrder", sameOrder(stop)).Return(stop, nil).Once()
+		mockRepo.On("TriggerOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.OrderKind == models.MARKET && o.Price.Equal(decimal.NewFromInt(80)) && o.HeldAmount.Equal(decimal.NewFromInt(2))
+		})).Return(stop, nil).Once()
+		mockRepo.On("MakeTransactionSell", bestBuy, sameOrder(stop), decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("MakeTransactionSell", worseBuy, sameOrder(stop), decimalEqual("1")).Return(nil).Once()
+
+		order, err := eng.Submit(stop)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, order.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must cancel a triggered stop-market order its owner cannot hold for", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		nextSell := limitOrder(models.SELL, "1", "120")
+		stop := stopOrder(models.BUY, models.STOP_MARKET, "1", "100", "0")
+		stop.Status = models.PENDING_TRIGGER
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{sell, nextSell}, nil)
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{stop}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("TriggerOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Id == stop.Id && o.HeldAmount.Equal(decimal.NewFromInt(120))
+		})).Return(models.Orders{}, models.ErrorInsufficientBalance).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, stop.Id.String(), models.CloseReasonNoBalance).Return(stop, nil).Once()
+
+		_, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "MakeTransactionBuy", sameOrder(stop), mock.Anything, mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+	})
+
+	t.Run("Must cancel a triggered stop-market order whose notional is above its owner's limit", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		nextSell := limitOrder(models.SELL, "1", "120")
+		stop := stopOrder(models.BUY, models.STOP_MARKET, "1", "100", "0")
+		stop.Status = models.PENDING_TRIGGER
+		stop.NotionalLimit = decimal.NewFromInt(110)
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{sell, nextSell}, nil)
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{stop}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, stop.Id.String(), models.CloseReasonOverLimit).Return(stop, nil).Once()
+
+		_, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "TriggerOrder", mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+	})
+
+	t.Run("Must not trigger a stop order that was cancelled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		stop := stopOrder(models.SELL, models.STOP_LIMIT, "1", "100", "90")
+		mockRepo.On("CreateOrder", sameOrder(stop)).Return(stop, nil).Once()
+
+		_, err := eng.Submit(stop)
+		assert.NoError(t, err)
+
+		stop.Status = models.PENDING_TRIGGER
+		mockRepo.On("GetOrderById", stop.Id.String()).Return(stop, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, stop.Id.String(), models.CloseReasonRequested).Return(stop, nil).Once()
+
+		_, err = eng.UpdateStatus(stop.Id.String(), models.CANCEL, models.CloseReasonRequested)
+		assert.NoError(t, err)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+
+		_, err = eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "TriggerOrder", mock.Anything)
+	})
+
+	t.Run("Must trigger on start the stop orders the last trade of their market already reached", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		stop := stopOrder(models.SELL, models.STOP_LIMIT, "1", "95", "94")
+		stop.Status = models.PENDING_TRIGGER
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{}, nil)
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{stop}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{models.DefaultMarket: decimal.NewFromInt(90)}, nil)
+		mockRepo.On("TriggerOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Id == stop.Id && o.OrderKind == models.LIMIT && o.Status == models.OPEN
+		})).Return(stop, nil).Once()
+
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Price.Equal(decimal.NewFromInt(94)))
+	})
+
+	t.Run("Must trigger again on the next execution a stop order whose trigger failed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		stop := stopOrder(models.BUY, models.STOP_LIMIT, "1", "100", "100")
+		stop.Status = models.PENDING_TRIGGER
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{sell}, nil)
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{stop}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("TriggerOrder", sameOrder(stop)).Return(models.Orders{}, errors.New("connection reset")).Once()
+
+		_, err := eng.Submit(buy)
+		assert.NoError(t, err)
+
+		// The reloaded book has the stop order waiting again, and the sell still resting.
+		again := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(again)).Return(again, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(again), sell, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("TriggerOrder", sameOrder(stop)).Return(stop, nil).Once()
+
+		_, err = eng.Submit(again)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Bids, 1)
+		assert.Empty(t, book.Asks)
+	})
+
+	t.Run("Must keep triggering the other stop orders when the trigger of one fails", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "2", "100")
+		failing := stopOrder(models.BUY, models.STOP_LIMIT, "1", "100", "100")
+		failing.Status = models.PENDING_TRIGGER
+		stop := stopOrder(models.BUY, models.STOP_LIMIT, "1", "100", "100")
+		stop.Status = models.PENDING_TRIGGER
+
+		mockRepo.On("ListRestingOrders").Return([]models.Orders{sell}, nil)
+		mockRepo.On("ListPendingStopOrders").Return([]models.Orders{failing, stop}, nil)
+		mockRepo.On("ListLastPrices").Return(map[string]decimal.Decimal{}, nil)
+		eng := engine.NewEngine(mockRepo)
+		assert.NoError(t, eng.Start())
+		t.Cleanup(eng.Stop)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("TriggerOrder", sameOrder(failing)).Return(models.Orders{}, errors.New("connection reset")).Once()
+		mockRepo.On("TriggerOrder", sameOrder(stop)).Return(stop, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(stop), sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+
+		_, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNumberOfCalls(t, "ListPendingStopOrders", 2)
+	})
+}
+
+// ocoPair links the two orders to each other.
+func ocoPair(first, second models.Orders) (models.Orders, models.Orders) {
+	first.OwnerOrderId = second.OwnerOrderId
+	first.LinkedOrderId = &second.Id
+	second.LinkedOrderId = &first.Id
+	return first, second
+}
+
+func TestSubmitOcoOrders(t *testing.T) {
+	t.Run("Must take the other order of an OCO pair out of the book when one is partially filled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		takeProfit, stopLoss := ocoPair(limitOrder(models.SELL, "1", "110"), stopOrder(models.SELL, models.STOP_LIMIT, "1", "90", "89"))
+		eng := startEngine(t, mockRepo)
+
+		mockRepo.On("CreateOrders", mock.Anything).Return(-1, nil).Once()
+
+		_, _, err := eng.SubmitBatch([]models.Orders{takeProfit, stopLoss})
+		assert.NoError(t, err)
+
+		buy := limitOrder(models.BUY, "0.5", "110")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sameOrder(takeProfit), decimalEqual("0.5")).Return(nil).Once()
+
+		_, err = eng.Submit(buy)
+		assert.NoError(t, err)
+
+		// An execution at the stop price no longer triggers the cancelled stop-loss.
+		bid := limitOrder(models.BUY, "1", "90")
+		mockRepo.On("CreateOrder", sameOrder(bid)).Return(bid, nil).Once()
+		_, err = eng.Submit(bid)
+		assert.NoError(t, err)
+
+		sell := limitOrder(models.SELL, "1", "90")
+		mockRepo.On("CreateOrder", sameOrder(sell)).Return(sell, nil).Once()
+		mockRepo.On("MakeTransactionSell", sameOrder(bid), sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+
+		_, err = eng.Submit(sell)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "TriggerOrder", mock.Anything)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.RequireFromString("0.5")))
+	})
+
+	t.Run("Must not place an order of a batch whose OCO pair was filled while placing it", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		crossing, resting := ocoPair(limitOrder(models.BUY, "1", "100"), limitOrder(models.BUY, "1", "95"))
+
+		mockRepo.On("CreateOrders", mock.Anything).Return(-1, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(crossing), sell, decimalEqual("1")).Return(nil).Once()
+
+		placed, _, err := eng.SubmitBatch([]models.Orders{crossing, resting})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, placed[0].Status)
+		assert.Equal(t, models.CANCEL, placed[1].Status)
+		assert.Equal(t, models.CloseReasonOco, placed[1].CloseReason)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+	})
+
+	t.Run("Must take the other order of an OCO pair out of the book when one is cancelled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		first, second := ocoPair(limitOrder(models.SELL, "1", "110"), limitOrder(models.SELL, "1", "120"))
+		eng := startEngine(t, mockRepo, first, second)
+
+		mockRepo.On("GetOrderById", first.Id.String()).Return(first, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, first.Id.String(), models.CloseReasonRequested).Return(first, nil).Once()
+
+		_, err := eng.UpdateStatus(first.Id.String(), models.CANCEL, models.CloseReasonRequested)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+	})
+}
+
+func icebergOrder(typeOrder int, quantity, displayQuantity, price string) models.Orders {
+	order := limitOrder(typeOrder, quantity, price)
+	order.DisplayQuantity = decimal.RequireFromString(displayQuantity)
+	return order
+}
+
+func TestSubmitIcebergOrder(t *testing.T) {
+	t.Run("Must show only the slice of an iceberg order in the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		iceberg := icebergOrder(models.SELL, "3", "1", "100")
+		eng := startEngine(t, mockRepo, iceberg)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.NewFromInt(1)))
+
+		buy := limitOrder(models.BUY, "0.4", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), iceberg, decimalEqual("0.4")).Return(nil).Once()
+
+		_, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		book, _ = eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.RequireFromString("0.6")))
+	})
+
+	t.Run("Must fill an iceberg order only up to its slice and send the next one to the back of its price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		iceberg := icebergOrder(models.SELL, "3", "1", "100")
+		other := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, iceberg, other)
+
+		buy := limitOrder(models.BUY, "1.5", "100")
+		mockRepo.On("CreateOrder", sameOrder(buy)).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), iceberg, decimalEqual("1")).Return(nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), other, decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(buy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Quantity.Equal(decimal.RequireFromString("1.5")))
+		assert.Equal(t, 2, book.Asks[0].Orders)
+	})
+
+	t.Run("Must show a whole slice of an iceberg order partially filled before it rests", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "0.5", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		iceberg := icebergOrder(models.BUY, "3", "1", "100")
+		mockRepo.On("CreateOrder", sameOrder(iceberg)).Return(iceberg, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(iceberg), sell, decimalEqual("0.5")).Return(nil).Once()
+
+		matched, err := eng.Submit(iceberg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PARTIALLY_FILLED, matched.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Len(t, book.Bids, 1)
+		assert.True(t, book.Bids[0].Quantity.Equal(decimal.NewFromInt(1)))
+	})
+}
+
+func TestUpdateStatus(t *testing.T) {
+	t.Run("Must remove a canceled order from the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		mockRepo.On("GetOrderById", sell.Id.String()).Return(sell, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.CANCEL, sell.Id.String(), models.CloseReasonRequested).Return(sell, nil).Once()
+
+		order, err := eng.UpdateStatus(sell.Id.String(), models.CANCEL, models.CloseReasonRequested)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.CANCEL, order.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Asks)
+	})
+
+	t.Run("Must match a waiting order when it is opened", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, sell)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		buy.Status = models.WAITING
+		buy.HeldAmount = decimal.NewFromInt(100)
+
+		mockRepo.On("GetOrderById", buy.Id.String()).Return(buy, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.OPEN, buy.Id.String(), models.CloseReasonRequested).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+
+		order, err := eng.UpdateStatus(buy.Id.String(), models.OPEN, models.CloseReasonRequested)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, order.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must queue a reopened order behind its price level, also after the book is rebuilt", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		now := time.Now()
+		reopened := limitOrder(models.BUY, "1", "100")
+		reopened.CreatedAt = now.Add(-time.Hour)
+		reopened.Status = models.WAITING
+		resting := limitOrder(models.BUY, "1", "100")
+		resting.CreatedAt = now.Add(-time.Minute)
+		eng := startEngine(t, mockRepo, resting)
+
+		stored := reopened
+		stored.Status = models.OPEN
+		stored.PriorityAt = &now
+		mockRepo.On("GetOrderById", reopened.Id.String()).Return(reopened, nil).Once()
+		mockRepo.On("UpdateStatusOrder", models.OPEN, reopened.Id.String(), models.CloseReasonRequested).Return(stored, nil).Once()
+
+		order, err := eng.UpdateStatus(reopened.Id.String(), models.OPEN, models.CloseReasonRequested)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &now, order.PriorityAt)
+
+		sell := limitOrder(models.SELL, "1", "100")
+		mockRepo.On("CreateOrder", mock.Anything).Return(sell, nil).Once()
+		mockRepo.On("MakeTransactionSell", resting, sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+
+		_, err = eng.Submit(sell)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		// Rebuilt from the stored orders, the reopened order is still behind, even when
+		// the repository lists it first.
+		rebuiltRepo := new(MockRepo)
+		rebuilt := startEngine(t, rebuiltRepo, stored, resting)
+		rebuiltRepo.On("CreateOrder", mock.Anything).Return(sell, nil).Once()
+		rebuiltRepo.On("MakeTransactionSell", resting, sameOrder(sell), decimalEqual("1")).Return(nil).Once()
+
+		_, err = rebuilt.Submit(sell)
+
+		assert.NoError(t, err)
+		rebuiltRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should fail once the engine is stopped", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		eng := startEngine(t, mockRepo)
+		eng.Stop()
+
+		_, err := eng.UpdateStatus(uuid.New().String(), models.CANCEL, models.CloseReasonRequested)
+
+		assert.ErrorIs(t, err, models.ErrorEngineStopped)
+	})
+}
+
+func TestAmend(t *testing.T) {
+	t.Run("Must keep the priority of an order whose quantity decreases", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		first := limitOrder(models.SELL, "2", "100")
+		second := limitOrder(models.SELL, "1", "100")
+		eng := startEngine(t, mockRepo, first, second)
+
+		mockRepo.On("GetOrderById", first.Id.String()).Return(first, nil).Once()
+		mockRepo.On("AmendOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Quantity.Equal(decimal.NewFromInt(1)) && o.PriorityAt == nil
+		})).Return(first, nil).Once()
+
+		amended, err := eng.Amend(first.Id.String(), first.Price, decimal.NewFromInt(1))
+
+		assert.NoError(t, err)
+		assert.True(t, amended.HeldAmount.Equal(decimal.NewFromInt(1)))
+		mockRepo.AssertExpectations(t)
+
+		buy := limitOrder(models.BUY, "1", "100")
+		mockRepo.On("CreateOrder", mock.Anything).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sameOrder(first), decimalEqual("1")).Return(nil).Once()
+
+		_, err = eng.Submit(buy)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must match again an order whose new price crosses the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		sell := limitOrder(models.SELL, "1", "105")
+		buy := limitOrder(models.BUY, "1", "100")
+		buy.HeldAmount = decimal.NewFromInt(100)
+		eng := startEngine(t, mockRepo, sell, buy)
+
+		mockRepo.On("GetOrderById", buy.Id.String()).Return(buy, nil).Once()
+		mockRepo.On("AmendOrder", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Price.Equal(decimal.NewFromInt(105)) && o.HeldAmount.Equal(decimal.NewFromInt(105)) && o.PriorityAt != nil
+		})).Return(buy, nil).Once()
+		mockRepo.On("MakeTransactionBuy", sameOrder(buy), sell, decimalEqual("1")).Return(nil).Once()
+
+		amended, err := eng.Amend(buy.Id.String(), decimal.NewFromInt(105), buy.Quantity)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DONE, amended.Status)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+		assert.Empty(t, book.Asks)
+	})
+}
+
+func TestCancelClientOrders(t *testing.T) {
+	t.Run("Must take the cancelled orders out of the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		owner := uuid.New()
+		bid := limitOrder(models.BUY, "1", "99")
+		bid.OwnerOrderId = owner
+		ask := limitOrder(models.SELL, "1", "101")
+		ask.OwnerOrderId = owner
+		other := limitOrder(models.SELL, "1", "102")
+		eng := startEngine(t, mockRepo, bid, ask, other)
+
+		filter := models.OrderCancelFilter{}
+		mockRepo.On("CancelClientOrders", owner.String(), filter, models.CloseReasonCancelAll).Return([]models.Orders{bid, ask}, nil).Once()
+
+		cancelled, err := eng.CancelClientOrders(owner.String(), filter, models.CloseReasonCancelAll)
+
+		assert.NoError(t, err)
+		assert.Len(t, cancelled, 2)
+		mockRepo.AssertExpectations(t)
+
+		book, _ := eng.Book(models.DefaultMarket, 10)
+		assert.Empty(t, book.Bids)
+		assert.Len(t, book.Asks, 1)
+		assert.True(t, book.Asks[0].Price.Equal(other.Price))
+	})
+}
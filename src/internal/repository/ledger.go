@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"MB-test/src/models"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// balanceColumns maps the client accounts of the ledger to the balance columns that
+// keep them.
+var balanceColumns = map[string]string{
+	models.AccountAvailable: "available",
+	models.AccountHeld:      "held",
+}
+
+// post records the journal and applies its entries to the balances of the clients,
+// inside the given transaction. Balances are updated in client and asset order, and
+// a client account that would become negative fails the whole journal with
+// ErrorInsufficientBalance.
+func post(tx *gorm.DB, journal models.Journal) error {
+	if len(journal.Entries) == 0 {
+		return nil
+	}
+
+	if !journal.Balanced() {
+		return models.ErrorUnbalancedJournal
+	}
+
+	type balanceKey struct {
+		clientId uuid.UUID
+		asset    string
+	}
+
+	changes := map[balanceKey]map[string]decimal.Decimal{}
+	for _, e := range journal.Entries {
+		if e.ClientId == nil {
+			continue
+		}
+		key := balanceKey{*e.ClientId, e.Asset}
+		if changes[key] == nil {
+			changes[key] = map[string]decimal.Decimal{}
+		}
+		column := balanceColumns[e.Account]
+		changes[key][column] = changes[key][column].Add(e.Amount)
+	}
+
+	keys := make([]balanceKey, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].clientId != keys[j].clientId {
+			return keys[i].clientId.String() < keys[j].clientId.String()
+		}
+		return keys[i].asset < keys[j].asset
+	})
+
+	for _, key := range keys {
+		// The first movement of an asset opens the balance of the client.
+		balance := models.Balance{ClientId: key.clientId, Asset: key.asset}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&balance).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		query := tx.Model(&models.Balance{}).Where("client_id = ? AND asset = ?", key.clientId, key.asset)
+		updates := map[string]interface{}{}
+		for column, amount := range changes[key] {
+			updates[column] = gorm.Expr(column+" + ?", amount)
+			if amount.IsNegative() {
+				query = query.Where(column+" >= ?", amount.Neg())
+			}
+		}
+
+		result := query.Updates(updates)
+		if result.Error != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrorInsufficientBalance
+		}
+	}
+
+	if err := tx.Create(&journal).Error; err != nil {
+		return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+	}
+
+	return nil
+}
+
+// holdJournal moves amount of the owner's available balance into the hold of the
+// order, or back from the hold when amount is negative.
+func holdJournal(order models.Orders, amount decimal.Decimal) models.Journal {
+	asset := order.HoldAsset()
+	available, held := models.AvailableAccount(order.OwnerOrderId), models.HeldAccount(order.OwnerOrderId)
+
+	if amount.IsNegative() {
+		journal := models.NewJournal(models.JournalRelease, &order.Id)
+		journal.Transfer(asset, amount.Neg(), held, available)
+		return journal
+	}
+
+	journal := models.NewJournal(models.JournalHold, &order.Id)
+	journal.Transfer(asset, amount, available, held)
+	return journal
+}
+
+// Deposit credits the available balance of the client with amount of the asset.
+func (r Repository) Deposit(clientId string, asset string, amount decimal.Decimal) (models.Journal, error) {
+	journal := models.Journal{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		client, err := lockClient(tx, clientId)
+		if err != nil {
+			return err
+		}
+
+		journal = models.NewJournal(models.JournalDeposit, nil)
+		journal.Transfer(asset, amount, models.ExternalAccount(), models.AvailableAccount(client.Id))
+
+		return post(tx, journal)
+	})
+	if err != nil {
+		return models.Journal{}, err
+	}
+
+	return journal, nil
+}
+
+// lockClient locks the client until the end of the transaction.
+func lockClient(tx *gorm.DB, clientId string) (models.Client, error) {
+	client := models.Client{}
+	if err := forUpdate(tx).Where("id = ?", clientId).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return client, models.ErrorNotFound
+		}
+		return client, models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+	}
+	return client, nil
+}
+
+// ListLedgerEntries returns one page of the ledger entries of the client, newest
+// first, and how many entries the client has.
+func (r Repository) ListLedgerEntries(clientId string, page models.Pagination) ([]models.LedgerEntry, int64, error) {
+	entries := []models.LedgerEntry{}
+	var total int64
+
+	query := r.DB.Model(&models.LedgerEntry{}).Where("client_id = ?", clientId)
+	if result := query.Count(&total); result.Error != nil {
+		return entries, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	result := r.DB.Where("client_id = ?", clientId).
+		Order("created_at DESC, id ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&entries)
+	if result.Error != nil {
+		return entries, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return entries, total, nil
+}
+
+// ReconcileLedger compares the balances kept for every client with the sum of the
+// entries of the matching ledger account, returning the ones that differ. An account
+// with entries but without a balance is compared against zero.
+func (r Repository) ReconcileLedger() ([]models.LedgerMismatch, error) {
+	mismatches := []models.LedgerMismatch{}
+
+	sums := []struct {
+		ClientId uuid.UUID
+		Account  string
+		Asset    string
+		Amount   decimal.Decimal
+	}{}
+	result := r.DB.Model(&models.LedgerEntry{}).
+		Select("client_id, account, asset, SUM(amount) AS amount").
+		Where("client_id IS NOT NULL").
+		Group("client_id, account, asset").
+		Order("client_id, asset, account").
+		Scan(&sums)
+	if result.Error != nil {
+		return mismatches, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	type accountKey struct {
+		clientId uuid.UUID
+		account  string
+		asset    string
+	}
+
+	ledger := map[accountKey]decimal.Decimal{}
+	for _, s := range sums {
+		ledger[accountKey{s.ClientId, s.Account, s.Asset}] = s.Amount
+	}
+
+	balances := []models.Balance{}
+	if result := r.DB.Order("client_id, asset").Find(&balances); result.Error != nil {
+		return mismatches, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	kept := map[accountKey]bool{}
+	for _, balance := range balances {
+		for _, account := range []string{models.AccountAvailable, models.AccountHeld} {
+			key := accountKey{balance.ClientId, account, balance.Asset}
+			kept[key] = true
+
+			sum := ledger[key]
+			if !balance.Of(account).Equal(sum) {
+				mismatches = append(mismatches, models.LedgerMismatch{
+					ClientId: balance.ClientId,
+					Account:  account,
+					Asset:    balance.Asset,
+					Balance:  balance.Of(account),
+					Ledger:   sum,
+				})
+			}
+		}
+	}
+
+	for _, s := range sums {
+		if kept[accountKey{s.ClientId, s.Account, s.Asset}] || s.Amount.IsZero() {
+			continue
+		}
+		mismatches = append(mismatches, models.LedgerMismatch{
+			ClientId: s.ClientId,
+			Account:  s.Account,
+			Asset:    s.Asset,
+			Balance:  decimal.Zero,
+			Ledger:   s.Amount,
+		})
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"MB-test/src/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (r Repository) CreateMarket(market models.Market) (models.Market, error) {
+	if result := r.DB.Create(&market); result.Error != nil {
+		return models.Market{}, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return market, nil
+}
+
+func (r Repository) GetMarket(symbol string) (models.Market, error) {
+	market := models.Market{}
+
+	result := r.DB.Where("symbol = ?", symbol).First(&market)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return market, models.ErrorNotFound
+	}
+
+	if result.Error != nil {
+		return market, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return market, nil
+}
+
+// ListMarkets returns every listed market, by symbol.
+func (r Repository) ListMarkets() ([]models.Market, error) {
+	markets := []models.Market{}
+	if result := r.DB.Order("symbol ASC").Find(&markets); result.Error != nil {
+		return markets, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return markets, nil
+}
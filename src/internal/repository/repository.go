@@ -4,27 +4,108 @@ import (
 	"MB-test/src/models"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Fees   models.FeeSchedule
+	Policy models.ScorePolicy
 }
 
-func NewRepository(db *gorm.DB) *Repository {
+func NewRepository(db *gorm.DB, fees models.FeeSchedule) *Repository {
 	return &Repository{
-		DB: db,
+		DB:     db,
+		Fees:   fees,
+		Policy: models.DefaultScorePolicy,
 	}
 }
 
+// holdChange returns how much the held balance of the owner changes when the order
+// goes from holding before to holding after. While the other order of its OCO pair
+// is resting both share one hold, the larger of the two, so only what goes beyond
+// the hold of the other order counts. The other order is locked until the end of the
+// given transaction.
+func holdChange(tx *gorm.DB, order models.Orders, before, after decimal.Decimal) (decimal.Decimal, error) {
+	if order.LinkedOrderId == nil {
+		return after.Sub(before), nil
+	}
+
+	linked := []models.Orders{}
+	if err := forUpdate(tx).Where("id = ?", *order.LinkedOrderId).Limit(1).Find(&linked).Error; err != nil {
+		return decimal.Zero, models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+	}
+
+	if len(linked) == 0 || !linked[0].IsResting() {
+		return after.Sub(before), nil
+	}
+
+	return models.SharedHoldChange(before, after, linked[0].HeldAmount), nil
+}
+
+// createOrder posts the hold of the HeldAmount of the order to the ledger and stores
+// the order, inside the given transaction. The second order of an OCO pair only holds
+// what goes beyond the hold of the first one.
+func createOrder(tx *gorm.DB, order models.Orders) error {
+	held, err := holdChange(tx, order, decimal.Zero, order.HeldAmount)
+	if err != nil {
+		return err
+	}
+
+	if err := post(tx, holdJournal(order, held)); err != nil {
+		return err
+	}
+
+	if result := insertOrder(tx, &order); result.Error != nil {
+		return models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return nil
+}
+
+// insertOrder stores the order alone. A client nested in it is neither created nor
+// updated and does not change its owner, as balances only change through the ledger.
+func insertOrder(tx *gorm.DB, order *models.Orders) *gorm.DB {
+	return tx.Omit(clause.Associations).Create(order)
+}
+
+// CreateOrder stores the order and moves its HeldAmount from the available to the
+// held balance of the owner in the same transaction.
 func (r Repository) CreateOrder(order models.Orders) (models.Orders, error) {
-	if result := r.DB.Create(&order); result.Error != nil {
-		return models.Orders{}, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		return createOrder(tx, order)
+	})
+	if err != nil {
+		return models.Orders{}, err
 	}
+
 	return order, nil
 }
 
+// CreateOrders stores every order, holding their balances, in one transaction: when
+// one of them fails none is created. The error is returned with the index of the order
+// that failed.
+func (r Repository) CreateOrders(orders []models.Orders) (int, error) {
+	failed := -1
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		for i, order := range orders {
+			if err := createOrder(tx, order); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+
+	return failed, err
+}
+
 func (r Repository) ListOrders() ([]models.Orders, error) {
 	orders := []models.Orders{}
 	if result := r.DB.Find(&orders); result.Error != nil {
@@ -34,167 +115,381 @@ func (r Repository) ListOrders() ([]models.Orders, error) {
 	return orders, nil
 }
 
+// restingStatuses are the statuses of the orders that still hold funds, in the book
+// or waiting to enter it.
+var restingStatuses = []int{models.OPEN, models.WAITING, models.PARTIALLY_FILLED, models.PENDING_TRIGGER}
+
+// CountOpenOrders returns how many orders of the client are OPEN, WAITING, PARTIALLY_FILLED
+// or PENDING_TRIGGER.
+func (r Repository) CountOpenOrders(clientId string) (int64, error) {
+	var count int64
+
+	result := r.DB.Model(&models.Orders{}).
+		Where("owner_order_id = ?", clientId).
+		Where("status IN ?", restingStatuses).
+		Count(&count)
+	if result.Error != nil {
+		return 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return count, nil
+}
+
 func (r Repository) GetOrderById(id string) (models.Orders, error) {
 	order := models.Orders{}
 
-	if result := r.DB.Find(&order).Where("id = ?", id); result.Error != nil {
-		return order, errors.New(result.Error.Error())
+	result := r.DB.Where("id = ?", id).First(&order)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return order, models.ErrorNotFound
+	}
+
+	if result.Error != nil {
+		return order, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
 	}
 
 	return order, nil
 }
 
-func (r Repository) FindMatchOrderToSell(order models.Orders) (models.Orders, error) {
-	orderMatch := models.Orders{}
+// ListRestingOrders returns the limit orders that can still be matched, from both
+// sides of the book, in time priority order so the book can be rebuilt from them.
+// An amended order that lost its priority counts from the amendment.
+func (r Repository) ListRestingOrders() ([]models.Orders, error) {
+	orders := []models.Orders{}
 
-	result := r.DB.Where("price_order_bt = ?", order.PriceOrderBT).
-		Where("price_order_brl >= ?", order.PriceOrderBRL).
-		Where("status = 1").
-		Where("type_order = 1").
-		Order("price_order_brl DESC").
-		First(&orderMatch)
+	result := r.DB.Where("status IN ?", []int{models.OPEN, models.PARTIALLY_FILLED}).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Where("order_kind = ?", models.LIMIT).
+		Order("COALESCE(priority_at, created_at) ASC").
+		Order("id ASC").
+		Find(&orders)
 
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return orderMatch, models.ErrorNotFound
+	if result.Error != nil {
+		return orders, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
 	}
 
+	return orders, nil
+}
+
+// ListPendingStopOrders returns the stop orders waiting for their trigger, oldest
+// first, so they are triggered in the order they were created.
+func (r Repository) ListPendingStopOrders() ([]models.Orders, error) {
+	orders := []models.Orders{}
+
+	result := r.DB.Where("status = ?", models.PENDING_TRIGGER).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("created_at ASC").
+		Order("id ASC").
+		Find(&orders)
+
 	if result.Error != nil {
-		return orderMatch, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+		return orders, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
 	}
 
-	return orderMatch, nil
+	return orders, nil
 }
 
-func (r Repository) FindMatchOrderToBuy(order models.Orders) (models.Orders, error) {
-	orderMatch := models.Orders{}
+// TriggerOrder stores a triggered stop order as the limit or market order it became,
+// with its price, priority and HeldAmount, adjusting the owner's held balance in the
+// same transaction. A stop order that is no longer waiting for its trigger fails with
+// ErrorOrderConflict.
+func (r Repository) TriggerOrder(order models.Orders) (models.Orders, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current := models.Orders{}
+		if err := forUpdate(tx).Where("id = ?", order.Id).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrorNotFound
+			}
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		if current.Status != models.PENDING_TRIGGER {
+			return models.ErrorOrderConflict
+		}
+
+		difference, err := holdChange(tx, current, current.HeldAmount, order.HeldAmount)
+		if err != nil {
+			return err
+		}
+
+		if err := post(tx, holdJournal(order, difference)); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"status":       order.Status,
+			"order_kind":   order.OrderKind,
+			"price":        order.Price,
+			"held_amount":  order.HeldAmount,
+			"priority_at":  order.PriorityAt,
+			"slice_filled": order.SliceFilled,
+		}).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
 
-	result := r.DB.Where("price_order_bt = ?", order.PriceOrderBT).
-		Where("price_order_brl <= ?", order.PriceOrderBRL).
-		Where("status = 1").
-		Where("type_order = 2").
-		Order("price_order_brl ASC").
-		First(&orderMatch)
+		return nil
+	})
+	if err != nil {
+		return models.Orders{}, err
+	}
 
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return orderMatch, models.ErrorNotFound
+	return order, nil
+}
+
+// MakeTransactionBuy settles a buy order against a resting sell order, at the price of the sell order.
+func (r Repository) MakeTransactionBuy(buyOrder, sellOrder models.Orders, quantity decimal.Decimal) error {
+	return r.makeTransaction(buyOrder, sellOrder, quantity, sellOrder.Price, models.BUY)
+}
+
+// MakeTransactionSell settles a sell order against a resting buy order, at the price of the buy order.
+func (r Repository) MakeTransactionSell(buyOrder, sellOrder models.Orders, quantity decimal.Decimal) error {
+	return r.makeTransaction(buyOrder, sellOrder, quantity, buyOrder.Price, models.SELL)
+}
+
+func closeReasonAfterFill(order models.Orders, quantity decimal.Decimal) string {
+	if order.StatusAfterFill(quantity) == models.DONE {
+		return models.CloseReasonFilled
 	}
+	return ""
+}
 
-	if result.Error != nil {
-		return orderMatch, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+// forUpdate locks the selected rows until the end of the transaction.
+func forUpdate(tx *gorm.DB) *gorm.DB {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// lockOrders locks both orders of an execution, always in the same (id) order so two
+// transactions settling the same pair of orders cannot deadlock. It fails with
+// ErrorOrderConflict when either order can no longer be filled by quantity.
+func lockOrders(tx *gorm.DB, buyId, sellId uuid.UUID, quantity decimal.Decimal) (buyOrder, sellOrder models.Orders, err error) {
+	orders := []models.Orders{}
+	if err := forUpdate(tx).Where("id IN ?", []uuid.UUID{buyId, sellId}).Order("id").Find(&orders).Error; err != nil {
+		return buyOrder, sellOrder, models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+	}
+
+	for _, order := range orders {
+		if order.Id == buyId {
+			buyOrder = order
+		} else {
+			sellOrder = order
+		}
+	}
+
+	for _, order := range []models.Orders{buyOrder, sellOrder} {
+		if order.Status != models.OPEN && order.Status != models.PARTIALLY_FILLED {
+			return buyOrder, sellOrder, models.ErrorOrderConflict
+		}
+		if order.RemainingQuantity().LessThan(quantity) {
+			return buyOrder, sellOrder, models.ErrorOrderConflict
+		}
 	}
 
-	return orderMatch, nil
+	return buyOrder, sellOrder, nil
 }
 
-func (r Repository) MakeTransactionBuy(buyOrder, sellOrder models.Orders) error {
-	tx := r.DB.Begin()
-	if tx.Error != nil {
-		return tx.Error
+// cancelLinked cancels the order linked to the given one in an OCO pair when it is
+// still in the book or waiting for its trigger, giving back to the owner what it
+// holds beyond the shared hold of the given order when that one is still resting,
+// inside the given transaction. It returns the linked order as it was before being
+// cancelled, and whether it was.
+func cancelLinked(tx *gorm.DB, order models.Orders) (models.Orders, bool, error) {
+	linked := models.Orders{}
+	if order.LinkedOrderId == nil {
+		return linked, false, nil
 	}
 
-	clientBuyer := models.Client{}
-	clientSeller := models.Client{}
+	if err := forUpdate(tx).Where("id = ?", *order.LinkedOrderId).First(&linked).Error; err != nil {
+		return linked, false, models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+	}
 
-	if err := tx.Where("id = ?", buyOrder.OwnerOrderId).First(&clientBuyer).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("err to found client: %w", err)
+	if !linked.IsResting() {
+		return linked, false, nil
 	}
 
-	if err := tx.Where("id = ?", sellOrder.OwnerOrderId).First(&clientSeller).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("err to found client: %w", err)
+	released, err := holdChange(tx, linked, linked.HeldAmount, decimal.Zero)
+	if err != nil {
+		return linked, false, err
 	}
 
-	if clientBuyer.BalanceBRL < sellOrder.PriceOrderBRL || clientSeller.BalanceBT < sellOrder.PriceOrderBT {
-		tx.Rollback()
-		return fmt.Errorf("customer with insufficient balance for this transaction")
+	if err := post(tx, holdJournal(linked, released)); err != nil {
+		return linked, false, err
 	}
 
-	if err := tx.Model(&clientBuyer).
-		Updates(map[string]interface{}{
-			"balance_brl": gorm.Expr("balance_brl - ?", sellOrder.PriceOrderBRL),
-			"balance_bt":  gorm.Expr("balance_bt + ?", sellOrder.PriceOrderBT),
-		}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("erro in transaction: %w", err)
+	if err := tx.Model(&linked).Updates(map[string]interface{}{
+		"status":       models.CANCEL,
+		"close_reason": models.CloseReasonOco,
+		"held_amount":  decimal.Zero,
+	}).Error; err != nil {
+		return linked, false, models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
 	}
 
-	if err := tx.Model(&clientSeller).
-		Updates(map[string]interface{}{
-			"balance_brl": gorm.Expr("balance_brl + ?", sellOrder.PriceOrderBRL),
-			"balance_bt":  gorm.Expr("balance_bt - ?", sellOrder.PriceOrderBT),
-		}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("erro in transaction: %w", err)
+	return linked, true, nil
+}
+
+// lockClients locks the buyer and the seller, in id order. Buyer and seller may be the same client.
+func lockClients(tx *gorm.DB, buyerId, sellerId uuid.UUID) (buyer, seller models.Client, err error) {
+	clients := []models.Client{}
+	if err := forUpdate(tx).Where("id IN ?", []uuid.UUID{buyerId, sellerId}).Order("id").Find(&clients).Error; err != nil {
+		return buyer, seller, fmt.Errorf("err to found client: %w", err)
 	}
 
-	if err := tx.Model(&buyOrder).Update("status", 3).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("erro to update orders status: %w", err)
+	for _, client := range clients {
+		if client.Id == buyerId {
+			buyer = client
+		}
+		if client.Id == sellerId {
+			seller = client
+		}
 	}
 
-	if err := tx.Model(&sellOrder).Update("status", 3).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("erro to update orders status: %w", err)
+	if buyer.Id != buyerId || seller.Id != sellerId {
+		return buyer, seller, fmt.Errorf("err to found client: %w", gorm.ErrRecordNotFound)
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("err in commit: %w", err)
+	return buyer, seller, nil
+}
+
+// fillUpdates returns the columns of an order changed by a fill of quantity that
+// releases released from its hold. When the order is the maker, the fill consumes
+// the slice shown by an iceberg order, and one whose slice is used up shows the next
+// one with a new time priority.
+func fillUpdates(order models.Orders, quantity, released decimal.Decimal, maker bool) map[string]interface{} {
+	updates := map[string]interface{}{
+		"filled_quantity": gorm.Expr("filled_quantity + ?", quantity),
+		"held_amount":     gorm.Expr("held_amount - ?", released),
+		"status":          order.StatusAfterFill(quantity),
+		"close_reason":    closeReasonAfterFill(order, quantity),
+	}
+	if !maker || !order.IsIceberg() {
+		return updates
 	}
 
-	return nil
+	if order.Replenishes(quantity) {
+		updates["slice_filled"] = decimal.Zero
+		updates["priority_at"] = time.Now()
+	} else {
+		updates["slice_filled"] = gorm.Expr("slice_filled + ?", quantity)
+	}
+
+	return updates
 }
 
-func (r Repository) MakeTransactionSell(buyOrder, sellOrder models.Orders) error {
+// settle charges the fees of the trade and returns the journal that settles it. The
+// buyer pays its fee in the base asset it receives and the seller in the quote asset,
+// each at the maker or taker rate of its own schedule depending on its side. The
+// notional goes from the held quote of the buyer to the seller and the quantity from
+// the held base of the seller to the buyer, the fees go to the fee account and
+// whatever each side held above what it paid goes back to its available balance.
+func settle(trade *models.Trade, buyerFees, sellerFees models.FeeSchedule, buyerReleased, sellerReleased decimal.Decimal) models.Journal {
+	base, quote := models.MarketAssets(trade.Market)
+	trade.BuyerFee = buyerFees.Fee(trade.Quantity, base, trade.TakerSide == models.SELL)
+	trade.SellerFee = sellerFees.Fee(trade.Notional, quote, trade.TakerSide == models.BUY)
+
+	buyer, seller := trade.BuyerId, trade.SellerId
+	journal := models.NewJournal(models.JournalTrade, &trade.Id)
+	journal.Transfer(quote, trade.Notional, models.HeldAccount(buyer), models.AvailableAccount(seller))
+	journal.Transfer(quote, trade.SellerFee, models.AvailableAccount(seller), models.FeesAccount())
+	journal.Transfer(quote, buyerReleased.Sub(trade.Notional), models.HeldAccount(buyer), models.AvailableAccount(buyer))
+	journal.Transfer(base, trade.Quantity, models.HeldAccount(seller), models.AvailableAccount(buyer))
+	journal.Transfer(base, trade.BuyerFee, models.AvailableAccount(buyer), models.FeesAccount())
+	journal.Transfer(base, sellerReleased.Sub(trade.Quantity), models.HeldAccount(seller), models.AvailableAccount(seller))
+
+	return journal
+}
+
+// makeTransaction updates both orders, records the execution as a trade and posts
+// the funds moved between buyer and seller, less the fees each one pays to the fee
+// account, to the ledger, all in the same transaction. An order of an OCO pair that
+// is filled, even partially, cancels the other one in it too. Both orders and
+// both clients are locked and read again, so an order filled or cancelled by a
+// concurrent operation fails with ErrorOrderConflict instead of being filled twice.
+func (r Repository) makeTransaction(buyOrder, sellOrder models.Orders, quantity, price decimal.Decimal, takerSide int) error {
+	notional := quantity.Mul(price)
+
 	tx := r.DB.Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
 
-	clientBuyer := models.Client{}
-	clientSeller := models.Client{}
+	buyOrder, sellOrder, err := lockOrders(tx, buyOrder.Id, sellOrder.Id, quantity)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 
-	if err := tx.Where("id = ?", buyOrder.OwnerOrderId).First(&clientBuyer).Error; err != nil {
+	clientBuyer, clientSeller, err := lockClients(tx, buyOrder.OwnerOrderId, sellOrder.OwnerOrderId)
+	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("err to found client: %w", err)
+		return err
 	}
 
-	if err := tx.Where("id = ?", sellOrder.OwnerOrderId).First(&clientSeller).Error; err != nil {
+	if buyOrder.Market != sellOrder.Market {
 		tx.Rollback()
-		return fmt.Errorf("err to found client: %w", err)
+		return fmt.Errorf("orders of different markets cannot be matched: %s and %s", buyOrder.Market, sellOrder.Market)
 	}
 
-	if clientBuyer.BalanceBRL < sellOrder.PriceOrderBRL || clientSeller.BalanceBT < sellOrder.PriceOrderBT {
+	// The buyer held the quote asset at its own limit price, anything above the
+	// execution price goes back to the available balance. Held balances that cannot
+	// cover the release fail the ledger posting.
+	buyerReleased := buyOrder.HoldConsumedBy(quantity)
+	sellerReleased := sellOrder.HoldConsumedBy(quantity)
+
+	if buyerReleased.LessThan(notional) {
 		tx.Rollback()
 		return fmt.Errorf("customer with insufficient balance for this transaction")
 	}
 
-	if err := tx.Model(&clientBuyer).
-		Updates(map[string]interface{}{
-			"balance_brl": gorm.Expr("balance_brl - ?", buyOrder.PriceOrderBRL),
-			"balance_bt":  gorm.Expr("balance_bt + ?", buyOrder.PriceOrderBT),
-		}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("erro in transaction: %w", err)
+	// The other order of an OCO pair is cancelled before the fill, while the filled
+	// order still rests with its hold, so only what the other one held beyond it is
+	// given back.
+	for _, order := range []models.Orders{buyOrder, sellOrder} {
+		if _, _, err := cancelLinked(tx, order); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("erro to cancel linked order: %w", err)
+		}
 	}
 
-	if err := tx.Model(&clientSeller).
-		Updates(map[string]interface{}{
-			"balance_brl": gorm.Expr("balance_brl + ?", buyOrder.PriceOrderBRL),
-			"balance_bt":  gorm.Expr("balance_bt - ?", buyOrder.PriceOrderBT),
-		}).Error; err != nil {
+	if err := tx.Model(&buyOrder).Updates(fillUpdates(buyOrder, quantity, buyerReleased, takerSide == models.SELL)).Error; err != nil {
 		tx.Rollback()
-		return fmt.Errorf("erro in transaction: %w", err)
+		return fmt.Errorf("erro to update orders status: %w", err)
 	}
 
-	if err := tx.Model(&buyOrder).Update("status", 3).Error; err != nil {
+	if err := tx.Model(&sellOrder).Updates(fillUpdates(sellOrder, quantity, sellerReleased, takerSide == models.BUY)).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("erro to update orders status: %w", err)
 	}
 
-	if err := tx.Model(&sellOrder).Update("status", 3).Error; err != nil {
+	trade := models.Trade{
+		Id:           uuid.New(),
+		Market:       buyOrder.Market,
+		BuyOrderId:   buyOrder.Id,
+		SellOrderId:  sellOrder.Id,
+		BuyerId:      buyOrder.OwnerOrderId,
+		SellerId:     sellOrder.OwnerOrderId,
+		MakerOrderId: sellOrder.Id,
+		TakerOrderId: buyOrder.Id,
+		TakerSide:    takerSide,
+		Price:        price,
+		Quantity:     quantity,
+		Notional:     notional,
+	}
+	if takerSide == models.SELL {
+		trade.MakerOrderId, trade.TakerOrderId = buyOrder.Id, sellOrder.Id
+	}
+
+	// Each side pays on what it receives, discounted by the score band of its owner.
+	buyerFees := r.Fees.Discounted(r.Policy.For(clientBuyer.Score).FeeDiscount)
+	sellerFees := r.Fees.Discounted(r.Policy.For(clientSeller.Score).FeeDiscount)
+	journal := settle(&trade, buyerFees, sellerFees, buyerReleased, sellerReleased)
+
+	if err := tx.Create(&trade).Error; err != nil {
 		tx.Rollback()
-		return fmt.Errorf("erro to update orders status: %w", err)
+		return fmt.Errorf("erro to record trade: %w", err)
+	}
+
+	if err := post(tx, journal); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("erro in transaction: %w", err)
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -204,18 +499,204 @@ func (r Repository) MakeTransactionSell(buyOrder, sellOrder models.Orders) error
 	return nil
 }
 
-func (r Repository) UpdateStatusOrder(status int, orderId string) (models.Orders, error) {
+// AmendOrder stores the new price, quantity and priority of a resting order and
+// adjusts the owner's held balance to its new HeldAmount in the same transaction.
+// An order closed or filled by a concurrent operation fails with ErrorOrderConflict.
+func (r Repository) AmendOrder(order models.Orders) (models.Orders, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current := models.Orders{}
+		if err := forUpdate(tx).Where("id = ?", order.Id).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrorNotFound
+			}
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		if !current.IsResting() || !current.FilledQuantity.Equal(order.FilledQuantity) {
+			return models.ErrorOrderConflict
+		}
+
+		// A positive difference is held from the available balance, a negative one goes back to it.
+		difference, err := holdChange(tx, current, current.HeldAmount, order.HeldAmount)
+		if err != nil {
+			return err
+		}
+		if err := post(tx, holdJournal(order, difference)); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"price":        order.Price,
+			"quantity":     order.Quantity,
+			"held_amount":  order.HeldAmount,
+			"priority_at":  order.PriorityAt,
+			"slice_filled": order.SliceFilled,
+		}).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Orders{}, err
+	}
+
+	return order, nil
+}
+
+// statusUpdates returns the columns changed when the order goes to status. A closed
+// order records the reason, and a WAITING order that reopens loses its time priority,
+// going to the back of its price level as it does in the book of the engine.
+func statusUpdates(order models.Orders, status int, reason string, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{"status": status}
+
+	if status == models.DONE || status == models.CANCEL {
+		updates["close_reason"] = reason
+	}
+
+	if order.Reopens(status) {
+		updates["priority_at"] = now
+	}
+
+	return updates
+}
+
+// UpdateStatusOrder changes the status of the order. When the order leaves the
+// book (DONE or CANCEL) whatever it still holds goes back to the owner's available
+// balance, the reason is recorded and the other order of its OCO pair, if any, is
+// cancelled. The order is locked while it changes, and an
+// order that was closed by a concurrent operation fails with ErrorOrderConflict.
+func (r Repository) UpdateStatusOrder(status int, orderId string, reason string) (models.Orders, error) {
 	order := models.Orders{}
-	if result := r.DB.Model(&order).Where("id = ?", orderId).Update("status", status); result.Error != nil {
-		return models.Orders{}, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := forUpdate(tx).Where("id = ?", orderId).First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrorNotFound
+			}
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		if !order.IsResting() {
+			return models.ErrorOrderConflict
+		}
+
+		updates := statusUpdates(order, status, reason, time.Now())
+
+		if (status == models.DONE || status == models.CANCEL) && order.HeldAmount.IsPositive() {
+			released, err := holdChange(tx, order, order.HeldAmount, decimal.Zero)
+			if err != nil {
+				return err
+			}
+
+			if err := post(tx, holdJournal(order, released)); err != nil {
+				return err
+			}
+
+			updates["held_amount"] = decimal.Zero
+		}
+
+		// The order leaves the book before the other order of its OCO pair is
+		// cancelled, so that one gives back all it holds.
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+		if priorityAt, ok := updates["priority_at"].(time.Time); ok {
+			order.PriorityAt = &priorityAt
+		}
+
+		if status == models.DONE || status == models.CANCEL {
+			if _, _, err := cancelLinked(tx, order); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Orders{}, err
 	}
+
 	return order, nil
 }
 
+// CancelClientOrders cancels, in one transaction, every order of the client still in
+// the book that matches the filter, and gives back to the client everything they held.
+// The other order of an OCO pair is cancelled too, even when it does not match the
+// filter. It returns the cancelled orders as they were before being cancelled.
+func (r Repository) CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error) {
+	orders := []models.Orders{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := forUpdate(tx).
+			Where("owner_order_id = ?", clientId).
+			Where("status IN ?", restingStatuses)
+		if filter.Market != "" {
+			query = query.Where("market = ?", filter.Market)
+		}
+		if filter.TypeOrder != 0 {
+			query = query.Where("type_order = ?", filter.TypeOrder)
+		}
+		if filter.MinPrice != nil {
+			query = query.Where("price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			query = query.Where("price <= ?", *filter.MaxPrice)
+		}
+
+		if err := query.Order("id").Find(&orders).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		// Each order leaves the book before the next one gives back its hold, so when
+		// both orders of an OCO pair match the filter their shared hold is given back once.
+		for _, order := range orders {
+			released, err := holdChange(tx, order, order.HeldAmount, decimal.Zero)
+			if err != nil {
+				return err
+			}
+
+			if err := post(tx, holdJournal(order, released)); err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.Orders{}).
+				Where("id = ?", order.Id).
+				Updates(map[string]interface{}{
+					"status":       models.CANCEL,
+					"close_reason": reason,
+					"held_amount":  decimal.Zero,
+				}).Error; err != nil {
+				return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+			}
+		}
+
+		for _, order := range orders {
+			linked, cancelled, err := cancelLinked(tx, order)
+			if err != nil {
+				return err
+			}
+			if cancelled {
+				orders = append(orders, linked)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return []models.Orders{}, err
+	}
+
+	return orders, nil
+}
+
 func (r Repository) GetClientById(id string) (models.Client, error) {
 	var client models.Client
 
-	result := r.DB.Where("id = ?", id).First(&client)
+	result := r.DB.Preload("Balances").Where("id = ?", id).First(&client)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return client, models.ErrorNotFound
@@ -227,3 +708,126 @@ func (r Repository) GetClientById(id string) (models.Client, error) {
 
 	return client, nil
 }
+
+// CreateClient stores the client with its opening balances and records them in the
+// ledger in the same transaction.
+func (r Repository) CreateClient(client models.Client) (models.Client, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&client).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		journal := models.NewOpeningJournal(client)
+		if len(journal.Entries) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&journal).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Client{}, err
+	}
+
+	return client, nil
+}
+
+// ListClients returns one page of the clients, oldest first, and how many clients exist.
+func (r Repository) ListClients(page models.Pagination) ([]models.Client, int64, error) {
+	clients := []models.Client{}
+	var total int64
+
+	if result := r.DB.Model(&models.Client{}).Count(&total); result.Error != nil {
+		return clients, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	result := r.DB.Preload("Balances").
+		Order("created_at ASC, id ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&clients)
+	if result.Error != nil {
+		return clients, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return clients, total, nil
+}
+
+func (r Repository) UpdateClientScore(id string, score int) (models.Client, error) {
+	result := r.DB.Model(&models.Client{}).Where("id = ?", id).Update("score", score)
+	if result.Error != nil {
+		return models.Client{}, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	if result.RowsAffected == 0 {
+		return models.Client{}, models.ErrorNotFound
+	}
+
+	return r.GetClientById(id)
+}
+
+func (r Repository) ListTrades(filter models.TradeFilter) ([]models.Trade, error) {
+	trades := []models.Trade{}
+
+	query := r.DB.Order("created_at DESC")
+	if filter.Market != "" {
+		query = query.Where("market = ?", filter.Market)
+	}
+	if filter.ClientId != "" {
+		query = query.Where("buyer_id = ? OR seller_id = ?", filter.ClientId, filter.ClientId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	if result := query.Find(&trades); result.Error != nil {
+		return trades, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return trades, nil
+}
+
+// ListLastPrices returns the price of the last trade of each market that had one.
+func (r Repository) ListLastPrices() (map[string]decimal.Decimal, error) {
+	trades := []models.Trade{}
+
+	result := r.DB.Model(&models.Trade{}).
+		Select("DISTINCT ON (market) market, price").
+		Order("market, created_at DESC").
+		Find(&trades)
+	if result.Error != nil {
+		return nil, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	prices := map[string]decimal.Decimal{}
+	for _, trade := range trades {
+		prices[trade.Market] = trade.Price
+	}
+
+	return prices, nil
+}
+
+// ListOrdersToExpire returns the orders still in the book whose expiry is due, or that
+// were created before staleBefore when it is informed.
+func (r Repository) ListOrdersToExpire(now time.Time, staleBefore *time.Time) ([]models.Orders, error) {
+	orders := []models.Orders{}
+
+	query := r.DB.Where("status IN ?", restingStatuses)
+	if staleBefore != nil {
+		query = query.Where("expires_at <= ? OR created_at <= ?", now, *staleBefore)
+	} else {
+		query = query.Where("expires_at <= ?", now)
+	}
+
+	if result := query.Order("created_at ASC").Find(&orders); result.Error != nil {
+		return orders, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return orders, nil
+}
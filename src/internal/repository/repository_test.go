@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"MB-test/src/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var fees = models.FeeSchedule{
+	MakerRate: decimal.RequireFromString("0.001"),
+	TakerRate: decimal.RequireFromString("0.002"),
+}
+
+func newTrade(market string, takerSide int, price, quantity string) models.Trade {
+	t := models.Trade{
+		Id:        uuid.New(),
+		Market:    market,
+		BuyerId:   uuid.New(),
+		SellerId:  uuid.New(),
+		TakerSide: takerSide,
+		Price:     decimal.RequireFromString(price),
+		Quantity:  decimal.RequireFromString(quantity),
+	}
+	t.Notional = t.Price.Mul(t.Quantity)
+	return t
+}
+
+// balance sums what the journal moves into the account in the asset.
+func balance(journal models.Journal, account models.LedgerAccount, asset string) decimal.Decimal {
+	total := decimal.Zero
+	for _, e := range journal.Entries {
+		if e.Account != account.Type || e.Asset != asset {
+			continue
+		}
+		if (e.ClientId == nil) != (account.ClientId == nil) || (e.ClientId != nil && *e.ClientId != *account.ClientId) {
+			continue
+		}
+		total = total.Add(e.Amount)
+	}
+	return total
+}
+
+func assertBalance(t *testing.T, journal models.Journal, account models.LedgerAccount, asset, expected string) {
+	t.Helper()
+	actual := balance(journal, account, asset)
+	assert.True(t, actual.Equal(decimal.RequireFromString(expected)), "%s %s of %v: expected %s, got %s", asset, account.Type, account.ClientId, expected, actual)
+}
+
+// assertBalanced checks that every asset of the journal sums to zero.
+func assertBalanced(t *testing.T, journal models.Journal) {
+	t.Helper()
+	totals := map[string]decimal.Decimal{}
+	for _, e := range journal.Entries {
+		totals[e.Asset] = totals[e.Asset].Add(e.Amount)
+	}
+	for asset, total := range totals {
+		assert.True(t, total.IsZero(), "%s entries sum to %s", asset, total)
+	}
+}
+
+func TestSettle(t *testing.T) {
+	t.Run("Must charge the taker rate to a taker buyer in the base asset and the maker rate to the seller in the quote asset", func(t *testing.T) {
+		trade := newTrade(models.DefaultMarket, models.BUY, "350000", "0.01")
+
+		// The buyer held 3600 BRL at its limit of 360000.
+		journal := settle(&trade, fees, fees, decimal.NewFromInt(3600), decimal.RequireFromString("0.01"))
+
+		assert.True(t, trade.BuyerFee.Equal(decimal.RequireFromString("0.00002")))
+		assert.True(t, trade.SellerFee.Equal(decimal.RequireFromString("3.5")))
+		assert.Equal(t, models.JournalTrade, journal.Kind)
+		assert.Equal(t, &trade.Id, journal.Reference)
+
+		buyer, seller := trade.BuyerId, trade.SellerId
+		assertBalance(t, journal, models.HeldAccount(buyer), models.AssetBRL, "-3600")
+		assertBalance(t, journal, models.AvailableAccount(buyer), models.AssetBRL, "100")
+		assertBalance(t, journal, models.AvailableAccount(seller), models.AssetBRL, "3496.5")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBRL, "3.5")
+		assertBalance(t, journal, models.HeldAccount(seller), models.AssetBT, "-0.01")
+		assertBalance(t, journal, models.AvailableAccount(buyer), models.AssetBT, "0.00998")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBT, "0.00002")
+		assertBalanced(t, journal)
+	})
+
+	t.Run("Must charge the maker rate to the buyer and the taker rate to a taker seller", func(t *testing.T) {
+		trade := newTrade(models.DefaultMarket, models.SELL, "350000", "0.01")
+
+		journal := settle(&trade, fees, fees, decimal.NewFromInt(3500), decimal.RequireFromString("0.01"))
+
+		assert.True(t, trade.BuyerFee.Equal(decimal.RequireFromString("0.00001")))
+		assert.True(t, trade.SellerFee.Equal(decimal.NewFromInt(7)))
+		assertBalance(t, journal, models.AvailableAccount(trade.SellerId), models.AssetBRL, "3493")
+		assertBalance(t, journal, models.AvailableAccount(trade.BuyerId), models.AssetBT, "0.00999")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBRL, "7")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBT, "0.00001")
+		assertBalanced(t, journal)
+	})
+
+	t.Run("Must charge each side at the rates of its own schedule", func(t *testing.T) {
+		trade := newTrade(models.DefaultMarket, models.BUY, "350000", "0.01")
+		exempt := fees.Discounted(decimal.NewFromInt(1))
+
+		journal := settle(&trade, exempt, fees, decimal.NewFromInt(3500), decimal.RequireFromString("0.01"))
+
+		assert.True(t, trade.BuyerFee.IsZero())
+		assert.True(t, trade.SellerFee.Equal(decimal.RequireFromString("3.5")))
+		assertBalance(t, journal, models.AvailableAccount(trade.BuyerId), models.AssetBT, "0.01")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBT, "0")
+		assertBalanced(t, journal)
+	})
+
+	t.Run("Must charge the fees in the assets of the market of the trade", func(t *testing.T) {
+		trade := newTrade("ETH-BT", models.BUY, "0.05", "2")
+
+		journal := settle(&trade, fees, fees, decimal.RequireFromString("0.1"), decimal.NewFromInt(2))
+
+		assert.True(t, trade.BuyerFee.Equal(decimal.RequireFromString("0.004")))
+		assert.True(t, trade.SellerFee.Equal(decimal.RequireFromString("0.0001")))
+		assertBalance(t, journal, models.FeesAccount(), "ETH", "0.004")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetBT, "0.0001")
+		assertBalance(t, journal, models.AvailableAccount(trade.SellerId), models.AssetBT, "0.0999")
+		assertBalance(t, journal, models.AvailableAccount(trade.BuyerId), "ETH", "1.996")
+		assertBalanced(t, journal)
+	})
+
+	t.Run("Must settle a trade of a USDT-quoted market in USDT", func(t *testing.T) {
+		trade := newTrade("BTC-USDT", models.BUY, "60000", "0.5")
+
+		journal := settle(&trade, fees, fees, decimal.NewFromInt(30250), decimal.RequireFromString("0.5"))
+
+		assert.True(t, trade.BuyerFee.Equal(decimal.RequireFromString("0.001")))
+		assert.True(t, trade.SellerFee.Equal(decimal.NewFromInt(30)))
+		assertBalance(t, journal, models.HeldAccount(trade.BuyerId), models.AssetUSDT, "-30250")
+		assertBalance(t, journal, models.AvailableAccount(trade.BuyerId), models.AssetUSDT, "250")
+		assertBalance(t, journal, models.AvailableAccount(trade.SellerId), models.AssetUSDT, "29970")
+		assertBalance(t, journal, models.FeesAccount(), models.AssetUSDT, "30")
+		assertBalance(t, journal, models.AvailableAccount(trade.BuyerId), "BTC", "0.499")
+		assertBalanced(t, journal)
+	})
+}
+
+func TestSharedHoldChange(t *testing.T) {
+	takeProfit, stopLoss := decimal.NewFromInt(1), decimal.RequireFromString("1.5")
+
+	t.Run("Must hold only the larger order of an OCO pair", func(t *testing.T) {
+		held := models.SharedHoldChange(decimal.Zero, takeProfit, decimal.Zero)
+		held = held.Add(models.SharedHoldChange(decimal.Zero, stopLoss, takeProfit))
+
+		assert.True(t, held.Equal(stopLoss), "expected %s, got %s", stopLoss, held)
+	})
+
+	t.Run("Must give the shared hold back once when both orders are cancelled", func(t *testing.T) {
+		// The first order leaves while the other one rests, the second one alone.
+		released := models.SharedHoldChange(takeProfit, decimal.Zero, stopLoss)
+		released = released.Add(stopLoss.Neg())
+
+		assert.True(t, released.Equal(stopLoss.Neg()), "expected -%s, got %s", stopLoss, released)
+	})
+
+	t.Run("Must leave the filled order with its own hold when the other one is cancelled", func(t *testing.T) {
+		released := models.SharedHoldChange(stopLoss, decimal.Zero, takeProfit)
+
+		assert.True(t, released.Equal(decimal.RequireFromString("-0.5")), "expected -0.5, got %s", released)
+		assert.True(t, models.SharedHoldChange(takeProfit, decimal.Zero, stopLoss).IsZero())
+	})
+}
+
+func TestStatusUpdates(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Must send a reopened waiting order to the back of its price level", func(t *testing.T) {
+		waiting := models.Orders{Status: models.WAITING}
+
+		updates := statusUpdates(waiting, models.OPEN, "", now)
+
+		assert.Equal(t, models.OPEN, updates["status"])
+		assert.Equal(t, now, updates["priority_at"])
+	})
+
+	t.Run("Must keep the priority of an order that is not reopened", func(t *testing.T) {
+		open := models.Orders{Status: models.OPEN}
+
+		updates := statusUpdates(open, models.CANCEL, models.CloseReasonRequested, now)
+
+		assert.Equal(t, models.CloseReasonRequested, updates["close_reason"])
+		assert.NotContains(t, updates, "priority_at")
+	})
+}
+
+func TestFillUpdates(t *testing.T) {
+	iceberg := models.Orders{
+		Quantity:        decimal.NewFromInt(3),
+		DisplayQuantity: decimal.NewFromInt(1),
+		Status:          models.OPEN,
+	}
+
+	t.Run("Must consume the slice of a resting iceberg order", func(t *testing.T) {
+		updates := fillUpdates(iceberg, decimal.RequireFromString("0.4"), decimal.Zero, true)
+
+		assert.Contains(t, updates, "slice_filled")
+		assert.NotContains(t, updates, "priority_at")
+	})
+
+	t.Run("Must show a new slice with a new priority when the slice is used up", func(t *testing.T) {
+		updates := fillUpdates(iceberg, decimal.NewFromInt(1), decimal.Zero, true)
+
+		assert.True(t, decimal.Zero.Equal(updates["slice_filled"].(decimal.Decimal)))
+		assert.Contains(t, updates, "priority_at")
+	})
+
+	t.Run("Must keep the slice of an iceberg order filled as taker", func(t *testing.T) {
+		updates := fillUpdates(iceberg, decimal.NewFromInt(1), decimal.Zero, false)
+
+		assert.NotContains(t, updates, "slice_filled")
+		assert.NotContains(t, updates, "priority_at")
+	})
+}
+
+func TestInsertOrder(t *testing.T) {
+	// Only builds the statements, no database is reached.
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+
+	t.Run("Must keep the owner of an order that carries another client", func(t *testing.T) {
+		owner := uuid.New()
+		order := models.Orders{
+			Id:           uuid.New(),
+			OwnerOrderId: owner,
+			Client: models.Client{
+				Id:       uuid.New(),
+				Balances: []models.Balance{{Asset: models.AssetBRL, Available: decimal.NewFromInt(1000000)}},
+			},
+		}
+
+		result := insertOrder(db, &order)
+
+		assert.NoError(t, result.Error)
+		assert.Equal(t, owner, order.OwnerOrderId)
+		assert.Contains(t, result.Statement.Vars, owner)
+		assert.NotContains(t, result.Statement.Vars, order.Client.Id)
+	})
+}
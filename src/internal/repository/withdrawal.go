@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"MB-test/src/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// RequestWithdrawal stores a REQUESTED withdrawal and holds its amount from the
+// available balance of the client in the same transaction.
+func (r Repository) RequestWithdrawal(clientId string, asset string, amount decimal.Decimal) (models.Withdrawal, error) {
+	withdrawal := models.Withdrawal{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		client, err := lockClient(tx, clientId)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		withdrawal = models.Withdrawal{
+			Id:        uuid.New(),
+			ClientId:  client.Id,
+			Asset:     asset,
+			Amount:    amount,
+			Status:    models.WithdrawalRequested,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		journal := models.NewJournal(models.JournalHold, &withdrawal.Id)
+		journal.Transfer(asset, amount, models.AvailableAccount(client.Id), models.HeldAccount(client.Id))
+		if err := post(tx, journal); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&withdrawal).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+
+	return withdrawal, nil
+}
+
+func (r Repository) GetWithdrawalById(id string) (models.Withdrawal, error) {
+	withdrawal := models.Withdrawal{}
+
+	result := r.DB.Where("id = ?", id).First(&withdrawal)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return withdrawal, models.ErrorNotFound
+	}
+
+	if result.Error != nil {
+		return withdrawal, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return withdrawal, nil
+}
+
+// ListWithdrawals returns one page of the withdrawals in the given statuses, oldest
+// first, and how many withdrawals are in them.
+func (r Repository) ListWithdrawals(statuses []string, page models.Pagination) ([]models.Withdrawal, int64, error) {
+	withdrawals := []models.Withdrawal{}
+	var total int64
+
+	if result := r.DB.Model(&models.Withdrawal{}).Where("status IN ?", statuses).Count(&total); result.Error != nil {
+		return withdrawals, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	result := r.DB.Where("status IN ?", statuses).
+		Order("created_at ASC, id ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&withdrawals)
+	if result.Error != nil {
+		return withdrawals, 0, models.NewError(models.ErrorKindDatabase, "database error: "+result.Error.Error(), models.StatusCodeInternal)
+	}
+
+	return withdrawals, total, nil
+}
+
+// UpdateWithdrawal stores the review of a withdrawal. A rejected withdrawal gives the
+// held amount back to the client and a sent one takes it out of the ledger, in the
+// same transaction. The withdrawal is locked, and one reviewed by a concurrent
+// operation since previous was read fails with ErrorWithdrawalConflict.
+func (r Repository) UpdateWithdrawal(previous, updated models.Withdrawal) (models.Withdrawal, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current := models.Withdrawal{}
+		if err := forUpdate(tx).Where("id = ?", updated.Id).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrorNotFound
+			}
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		if current.Status != previous.Status || current.ApprovedBy != previous.ApprovedBy {
+			return models.ErrorWithdrawalConflict
+		}
+
+		journal := models.Journal{}
+		switch updated.Status {
+		case models.WithdrawalRejected:
+			journal = models.NewJournal(models.JournalRelease, &updated.Id)
+			journal.Transfer(updated.Asset, updated.Amount, models.HeldAccount(updated.ClientId), models.AvailableAccount(updated.ClientId))
+		case models.WithdrawalSent:
+			journal = models.NewJournal(models.JournalWithdrawal, &updated.Id)
+			journal.Transfer(updated.Asset, updated.Amount, models.HeldAccount(updated.ClientId), models.ExternalAccount())
+		}
+		if err := post(tx, journal); err != nil {
+			return err
+		}
+
+		updated.UpdatedAt = time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"status":             updated.Status,
+			"approved_by":        updated.ApprovedBy,
+			"second_approved_by": updated.SecondApprovedBy,
+			"rejected_by":        updated.RejectedBy,
+			"sent_by":            updated.SentBy,
+			"updated_at":         updated.UpdatedAt,
+		}).Error; err != nil {
+			return models.NewError(models.ErrorKindDatabase, "database error: "+err.Error(), models.StatusCodeInternal)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"MB-test/src/internal/contracts"
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler periodically cancels the orders that expired or stayed in the book for too long.
+type Scheduler struct {
+	Service  contracts.OrderExpirationHandler
+	Interval time.Duration
+	MaxAge   time.Duration
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(service contracts.OrderExpirationHandler, interval, maxAge time.Duration) *Scheduler {
+	return &Scheduler{
+		Service:  service,
+		Interval: interval,
+		MaxAge:   maxAge,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the expiry in background, once every Interval, until Stop is called.
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case now := <-ticker.C:
+				s.run(now)
+			}
+		}
+	}()
+}
+
+// Stop ends the background expiry and waits for the running cycle to finish.
+func (s *Scheduler) Stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(now time.Time) {
+	expired, err := s.Service.ExpireOrders(now, s.MaxAge)
+	if err != nil {
+		log.Printf("Error expiring orders: %v\n", err)
+	}
+	if expired > 0 {
+		log.Printf("%d orders expired\n", expired)
+	}
+}
@@ -0,0 +1,40 @@
+package scheduler_test
+
+import (
+	"MB-test/src/internal/scheduler"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExpirer struct {
+	calls  atomic.Int32
+	maxAge atomic.Int64
+}
+
+func (f *fakeExpirer) ExpireOrders(now time.Time, maxAge time.Duration) (int, error) {
+	f.calls.Add(1)
+	f.maxAge.Store(int64(maxAge))
+	return 0, nil
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("Must expire orders periodically until stopped", func(t *testing.T) {
+		expirer := &fakeExpirer{}
+		s := scheduler.NewScheduler(expirer, 5*time.Millisecond, time.Hour)
+
+		s.Start()
+		assert.Eventually(t, func() bool {
+			return expirer.calls.Load() >= 2
+		}, time.Second, time.Millisecond)
+		s.Stop()
+
+		calls := expirer.calls.Load()
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Equal(t, calls, expirer.calls.Load())
+		assert.Equal(t, int64(time.Hour), expirer.maxAge.Load())
+	})
+}
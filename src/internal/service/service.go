@@ -5,17 +5,22 @@ import (
 	"MB-test/src/models"
 	"errors"
 	"fmt"
+	"log"
 	"reflect"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type Service struct {
-	Repo contracts.OperationsRepositoryHandle
+	Repo   contracts.OperationsRepositoryHandle
+	Engine contracts.MatchingEngineHandler
+	Policy models.ScorePolicy
 }
 
-func NewService(repo contracts.OperationsRepositoryHandle) *Service {
-	return &Service{Repo: repo}
+func NewService(repo contracts.OperationsRepositoryHandle, engine contracts.MatchingEngineHandler) *Service {
+	return &Service{Repo: repo, Engine: engine, Policy: models.DefaultScorePolicy}
 }
 
 func (s Service) ListOrders() ([]models.OrderDtoOutput, error) {
@@ -26,86 +31,340 @@ func (s Service) ListOrders() ([]models.OrderDtoOutput, error) {
 
 	var result []models.OrderDtoOutput
 	for _, o := range orders {
+		var stopPrice, displayQuantity *decimal.Decimal
+		if o.StopPrice.IsPositive() {
+			stopPrice = &o.StopPrice
+		}
+		if o.IsIceberg() {
+			displayQuantity = &o.DisplayQuantity
+		}
+
 		result = append(result, models.OrderDtoOutput{
-			Id:            o.Id,
-			OwnerOrderId:  o.OwnerOrderId,
-			PriceOrderBRL: o.PriceOrderBRL,
-			PriceOrderBT:  o.PriceOrderBT,
-			TypeOrder:     models.TranslateStatus(o.Status),
-			Status:        models.TranslateTypeOrder(o.TypeOrder),
+			Id:                o.Id,
+			OwnerOrderId:      o.OwnerOrderId,
+			Market:            o.Market,
+			Price:             o.Price,
+			StopPrice:         stopPrice,
+			Quantity:          o.Quantity,
+			DisplayQuantity:   displayQuantity,
+			FilledQuantity:    o.FilledQuantity,
+			RemainingQuantity: o.RemainingQuantity(),
+			Notional:          o.Notional(),
+			HeldAmount:        o.HeldAmount,
+			TypeOrder:         models.TranslateTypeOrder(o.TypeOrder),
+			OrderKind:         models.TranslateOrderKind(o.OrderKind),
+			TimeInForce:       models.TranslateTimeInForce(o.TimeInForce),
+			ExpiresAt:         o.ExpiresAt,
+			CloseReason:       o.CloseReason,
+			Status:            models.TranslateStatus(o.Status),
+			LinkedOrderId:     o.LinkedOrderId,
 		})
 	}
 
 	return result, nil
 }
 
-func (s Service) CreateOrder(order models.Orders) (string, error) {
+// prepareOrder validates a new order and fills in its defaults and id. It returns
+// the order ready to be submitted to the engine along with its owner. pending is how
+// many orders of the owner that can rest in the book were already accepted together
+// with this one, and count towards the open orders limit of its score band.
+func (s Service) prepareOrder(order models.Orders, pending int64) (models.Orders, models.Client, error) {
 	owner, err := s.Repo.GetClientById(order.OwnerOrderId.String())
 	if err != nil {
-		return "", err
+		return models.Orders{}, models.Client{}, err
 	}
 	if reflect.DeepEqual(owner, models.Client{}) {
-		return "", models.ErrorNotFound
+		return models.Orders{}, models.Client{}, models.ErrorNotFound
 	}
 
-	if order.TypeOrder == 1 && owner.BalanceBRL < order.PriceOrderBRL {
-		return "", models.ErrorInsufficientBalance
+	if order.Market == "" {
+		order.Market = models.DefaultMarket
 	}
 
-	if order.TypeOrder == 2 && owner.BalanceBT < order.PriceOrderBT {
-		return "", models.ErrorInsufficientBalance
+	market, err := s.market(order.Market)
+	if err != nil {
+		return models.Orders{}, models.Client{}, err
 	}
 
 	if order.TypeOrder < 1 || order.TypeOrder > 2 {
-		return "", models.ErrorInvalidTypeOrder
+		return models.Orders{}, models.Client{}, models.ErrorInvalidTypeOrder
 	}
 
 	if order.Status < 1 || order.Status > 4 {
-		return "", models.ErrorInvalidStatus
+		return models.Orders{}, models.Client{}, models.ErrorInvalidStatus
 	}
 
-	if order.PriceOrderBRL <= 0 || order.PriceOrderBT <= 0 {
-		return "", models.ErrorInvalidPriceOrder
+	if order.Status != models.OPEN && order.Status != models.WAITING {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidCreateOrderStatus
+	}
+
+	if order.OrderKind == 0 {
+		order.OrderKind = models.LIMIT
+	}
+
+	if order.OrderKind < models.LIMIT || order.OrderKind > models.STOP_MARKET {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidOrderKind
+	}
+
+	if (order.IsMarket() || order.IsStop()) && order.Status != models.OPEN {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidMarketOrderStatus
+	}
+
+	if order.TimeInForce == 0 {
+		order.TimeInForce = models.GTC
+	}
+
+	if order.TimeInForce < models.GTC || order.TimeInForce > models.GTD {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidTimeInForce
+	}
+
+	if (order.TimeInForce == models.IOC || order.TimeInForce == models.FOK) && order.Status != models.OPEN {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidTimeInForceStatus
+	}
+
+	if order.TimeInForce == models.GTD && (order.ExpiresAt == nil || !order.ExpiresAt.After(time.Now())) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidExpiresAt
+	}
+
+	if order.TimeInForce != models.GTD && order.ExpiresAt != nil {
+		return models.Orders{}, models.Client{}, models.ErrorExpiresAtWithoutGTD
+	}
+
+	// A stop order waits for its trigger before entering the book, where it may rest.
+	if order.IsStop() && order.TimeInForce != models.GTC && order.TimeInForce != models.GTD {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidStopTimeInForce
+	}
+
+	if order.IsStop() && !order.StopPrice.IsPositive() {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidStopPrice
+	}
+
+	if !order.IsStop() && !order.StopPrice.IsZero() {
+		return models.Orders{}, models.Client{}, models.ErrorStopPriceWithoutStop
+	}
+
+	if !order.IsMarket() && !order.Price.IsPositive() {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidPriceOrder
+	}
+
+	if !order.Quantity.IsPositive() {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidQuantityOrder
+	}
+
+	if !order.IsMarket() && !market.OnTick(order.Price) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidPriceTick
+	}
+
+	if order.IsStop() && !market.OnTick(order.StopPrice) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidStopPriceTick
+	}
+
+	if !market.OnLot(order.Quantity) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidQuantityLot
+	}
+
+	// Only the remainder of an order that rests in the book can be partly hidden.
+	if !order.DisplayQuantity.IsZero() && (order.IsMarket() || !order.CanRest()) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidIcebergOrder
+	}
+
+	if !order.DisplayQuantity.IsZero() && (!order.DisplayQuantity.IsPositive() || !order.DisplayQuantity.LessThan(order.Quantity)) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidDisplayQuantity
+	}
+
+	if order.IsIceberg() && !market.OnLot(order.DisplayQuantity) {
+		return models.Orders{}, models.Client{}, models.ErrorInvalidDisplayQuantityLot
+	}
+
+	// The value of a market order is only known once it executes, so only limit
+	// orders are held to the minimum notional.
+	if !order.IsMarket() && !market.MeetsMinNotional(order.Notional()) {
+		return models.Orders{}, models.Client{}, models.ErrorOrderBelowMinNotional
+	}
+
+	// The price of a market order is only known once the engine walks the book.
+	if order.IsMarket() {
+		order.Price = decimal.Zero
+	}
+
+	if owner.Available(order.HoldAsset()).LessThan(order.HoldFor(order.Quantity)) {
+		return models.Orders{}, models.Client{}, models.ErrorInsufficientBalance
+	}
+
+	band := s.Policy.For(owner.Score)
+	limit, ok := band.NotionalLimit(market.QuoteAsset)
+	if !ok {
+		return models.Orders{}, models.Client{}, models.ErrorNoNotionalLimit
+	}
+	if order.Notional().GreaterThan(limit) {
+		return models.Orders{}, models.Client{}, models.ErrorOrderNotionalLimit
+	}
+	order.NotionalLimit = limit
+
+	if order.CanRest() {
+		open, err := s.Repo.CountOpenOrders(owner.Id.String())
+		if err != nil {
+			return models.Orders{}, models.Client{}, err
+		}
+		if open+pending >= band.MaxOpenOrders {
+			return models.Orders{}, models.Client{}, models.ErrorOpenOrdersLimit
+		}
 	}
 
 	order.Id = uuid.New()
+	order.FilledQuantity = decimal.Zero
+	order.CloseReason = ""
+	order.LinkedOrderId = nil
+	// The time priority is given by the exchange, whatever the client sent.
+	order.CreatedAt = time.Now()
+	order.PriorityAt = nil
 
-	res, err := s.Repo.CreateOrder(order)
+	return order, owner, nil
+}
+
+func (s Service) CreateOrder(order models.Orders) (string, error) {
+	res, err := s.submitOrder(order)
 	if err != nil {
 		return "", err
 	}
 
-	err = s.FindMatchOrder(order)
+	return res.Id.String(), nil
+}
+
+func (s Service) submitOrder(order models.Orders) (models.Orders, error) {
+	order, _, err := s.prepareOrder(order, 0)
 	if err != nil {
-		if errors.Is(err, models.ErrorNotFound) {
-			return res.Id.String(), nil
-		}
+		return models.Orders{}, err
 	}
 
-	return res.Id.String(), nil
+	return s.Engine.Submit(order)
 }
 
-func (s Service) FindMatchOrder(orderToMatch models.Orders) error {
-	if orderToMatch.TypeOrder == models.SELL {
-		orderMatched, err := s.Repo.FindMatchOrderToSell(orderToMatch)
-		if err != nil {
-			if errors.Is(err, models.ErrorNotFound) {
-				return nil
+// CreateOrderBatch creates every order of the batch with the same rules as CreateOrder
+// and returns the result of each one. Without AllOrNothing each order is submitted on
+// its own; with it, the batch is only created when every order is valid and the owners
+// can hold all of them together.
+func (s Service) CreateOrderBatch(batch models.OrderBatchDtoInput) ([]models.OrderBatchResult, error) {
+	if len(batch.Orders) == 0 || len(batch.Orders) > models.MaxBatchOrders {
+		return []models.OrderBatchResult{}, models.ErrorInvalidBatchSize
+	}
+
+	results := make([]models.OrderBatchResult, 0, len(batch.Orders))
+
+	if !batch.AllOrNothing {
+		for i, order := range batch.Orders {
+			res, err := s.submitOrder(order)
+			results = append(results, models.NewOrderBatchResult(i, res.Id, err))
+		}
+		return results, nil
+	}
+
+	type holdKey struct {
+		owner uuid.UUID
+		asset string
+	}
+
+	var (
+		prepared = make([]models.Orders, len(batch.Orders))
+		errs     = make([]error, len(batch.Orders))
+		holds    = map[holdKey]decimal.Decimal{}
+		resting  = map[uuid.UUID]int64{}
+		rejected = false
+	)
+
+	for i, order := range batch.Orders {
+		order, owner, err := s.prepareOrder(order, resting[order.OwnerOrderId])
+		if err == nil && (order.IsMarket() || order.TimeInForce == models.FOK) {
+			err = models.ErrorInvalidAtomicBatchOrder
+		}
+
+		// Each order fits the balance on its own, the batch must fit it as a whole.
+		if err == nil {
+			key := holdKey{owner: owner.Id, asset: order.HoldAsset()}
+			holds[key] = holds[key].Add(order.HoldFor(order.Quantity))
+
+			if owner.Available(key.asset).LessThan(holds[key]) {
+				err = models.ErrorInsufficientBalance
 			}
-			return err
 		}
-		return s.Repo.MakeTransactionSell(orderMatched, orderToMatch)
 
-	} else {
-		orderMatched, err := s.Repo.FindMatchOrderToBuy(orderToMatch)
-		if err != nil {
-			if errors.Is(err, models.ErrorNotFound) {
-				return nil
+		if err == nil && order.CanRest() {
+			resting[owner.Id]++
+		}
+
+		prepared[i], errs[i] = order, err
+		rejected = rejected || err != nil
+	}
+
+	if !rejected {
+		orders, failed, err := s.Engine.SubmitBatch(prepared)
+		if err == nil {
+			for i, order := range orders {
+				results = append(results, models.NewOrderBatchResult(i, order.Id, nil))
+			}
+			return results, nil
+		}
+
+		for i := range errs {
+			if i == failed || failed < 0 {
+				errs[i] = err
 			}
-			return err
 		}
-		return s.Repo.MakeTransactionBuy(orderToMatch, orderMatched)
 	}
+
+	for i, err := range errs {
+		if err == nil {
+			err = models.ErrorBatchAborted
+		}
+		results = append(results, models.NewOrderBatchResult(i, uuid.Nil, err))
+	}
+
+	return results, nil
+}
+
+// CreateOcoOrder creates the two orders of an OCO group, linked to each other, and
+// returns their ids. Both follow the rules of CreateOrder, must belong to the same
+// client, market and side and be able to rest in the book, and are created together
+// or not at all. Only one of them can be filled, so they share one hold, the larger of
+// the two, and the client only needs the balance of the order that holds the most.
+func (s Service) CreateOcoOrder(input models.OrderOcoDtoInput) ([]uuid.UUID, error) {
+	if len(input.Orders) != models.OcoOrderCount {
+		return []uuid.UUID{}, models.ErrorInvalidOcoSize
+	}
+
+	prepared := make([]models.Orders, 0, models.OcoOrderCount)
+
+	for i, order := range input.Orders {
+		order, _, err := s.prepareOrder(order, int64(i))
+		if err != nil {
+			return []uuid.UUID{}, err
+		}
+
+		if !order.CanRest() {
+			return []uuid.UUID{}, models.ErrorInvalidOcoOrderKind
+		}
+
+		if i > 0 && (order.OwnerOrderId != prepared[0].OwnerOrderId || order.Market != prepared[0].Market || order.TypeOrder != prepared[0].TypeOrder) {
+			return []uuid.UUID{}, models.ErrorInvalidOcoOrders
+		}
+
+		prepared = append(prepared, order)
+	}
+
+	prepared[0].LinkedOrderId = &prepared[1].Id
+	prepared[1].LinkedOrderId = &prepared[0].Id
+
+	orders, _, err := s.Engine.SubmitBatch(prepared)
+	if err != nil {
+		return []uuid.UUID{}, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.Id)
+	}
+
+	return ids, nil
 }
 
 func (s Service) UpdateStatusOrder(status int, orderId string) (string, error) {
@@ -118,28 +377,182 @@ func (s Service) UpdateStatusOrder(status int, orderId string) (string, error) {
 		return "", models.ErrorNotFound
 	}
 
-	if order.Status == models.DONE {
-		return "", models.ErrorInvalidUpdateOrderDone
+	_, inEffect, err := order.StatusTransition(status)
+	if err != nil {
+		return "", err
 	}
 
-	if order.Status == models.CANCEL {
-		return "", models.ErrorInvalidUpdateOrderCancel
+	if inEffect {
+		return "status in effect for this order", nil
 	}
 
-	if order.Status == status {
-		return "status in effect for this order", nil
+	_, err = s.Engine.UpdateStatus(orderId, status, models.CloseReasonRequested)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("order %s updated", orderId), nil
+}
+
+// AmendOrder changes the price and/or quantity of a resting order, validating them
+// like CreateOrder against the rules of its market. The order must keep enough balance held for its new remainder.
+func (s Service) AmendOrder(orderId string, amendment models.OrderAmendDtoInput) (string, error) {
+	if amendment.Price == nil && amendment.Quantity == nil {
+		return "", models.ErrorEmptyAmendment
+	}
+
+	order, err := s.Repo.GetOrderById(orderId)
+	if err != nil {
+		return "", models.ErrorNotFound
+	}
+
+	price, quantity := order.Price, order.Quantity
+	if amendment.Price != nil {
+		price = *amendment.Price
+	}
+	if amendment.Quantity != nil {
+		quantity = *amendment.Quantity
 	}
 
-	if order.Status == models.WAITING && status != models.OPEN && status != models.CANCEL {
-		return "", models.ErrorInvalidUpdateOrderWaiting
+	if !price.IsPositive() {
+		return "", models.ErrorInvalidPriceOrder
 	}
 
-	_, err = s.Repo.UpdateStatusOrder(status, orderId)
+	if !quantity.IsPositive() {
+		return "", models.ErrorInvalidQuantityOrder
+	}
+
+	amended, _, err := order.Amend(price, quantity)
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("order %s updated", orderId), nil
+	market, err := s.market(order.Market)
+	if err != nil {
+		return "", err
+	}
+
+	if !market.OnTick(price) {
+		return "", models.ErrorInvalidPriceTick
+	}
+
+	if !market.OnLot(quantity) {
+		return "", models.ErrorInvalidQuantityLot
+	}
+
+	// The slice shown by an iceberg order must stay below its new quantity.
+	if amended.IsIceberg() && !amended.DisplayQuantity.LessThan(quantity) {
+		return "", models.ErrorInvalidDisplayQuantity
+	}
+
+	if amended.IsIceberg() && !market.OnLot(amended.DisplayQuantity) {
+		return "", models.ErrorInvalidDisplayQuantityLot
+	}
+
+	if !market.MeetsMinNotional(price.Mul(quantity)) {
+		return "", models.ErrorOrderBelowMinNotional
+	}
+
+	owner, err := s.Repo.GetClientById(order.OwnerOrderId.String())
+	if err != nil {
+		return "", err
+	}
+
+	limit, ok := s.Policy.For(owner.Score).NotionalLimit(market.QuoteAsset)
+	if !ok {
+		return "", models.ErrorNoNotionalLimit
+	}
+	if amended.Notional().GreaterThan(limit) {
+		return "", models.ErrorOrderNotionalLimit
+	}
+
+	// While the other order of an OCO pair rests, only what goes beyond its hold is held.
+	difference := amended.HeldAmount.Sub(order.HeldAmount)
+	if order.LinkedOrderId != nil {
+		linked, err := s.Repo.GetOrderById(order.LinkedOrderId.String())
+		if err != nil {
+			return "", err
+		}
+		if linked.IsResting() {
+			difference = models.SharedHoldChange(order.HeldAmount, amended.HeldAmount, linked.HeldAmount)
+		}
+	}
+
+	if owner.Available(order.HoldAsset()).LessThan(difference) {
+		return "", models.ErrorInsufficientBalance
+	}
+
+	if _, err := s.Engine.Amend(orderId, price, quantity); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("order %s amended", orderId), nil
+}
+
+// CancelClientOrders cancels every order of the client still in the book, optionally
+// only of one side and within a price range, and returns the ids of the cancelled orders.
+func (s Service) CancelClientOrders(clientId string, filter models.OrderCancelFilter) ([]uuid.UUID, error) {
+	if _, err := s.Repo.GetClientById(clientId); err != nil {
+		return []uuid.UUID{}, err
+	}
+
+	if filter.TypeOrder < 0 || filter.TypeOrder > 2 {
+		return []uuid.UUID{}, models.ErrorInvalidTypeOrder
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.GreaterThan(*filter.MaxPrice) {
+		return []uuid.UUID{}, models.ErrorInvalidPriceRange
+	}
+
+	orders, err := s.Engine.CancelClientOrders(clientId, filter, models.CloseReasonCancelAll)
+	if err != nil {
+		return []uuid.UUID{}, err
+	}
+
+	ids := []uuid.UUID{}
+	for _, order := range orders {
+		ids = append(ids, order.Id)
+	}
+
+	return ids, nil
+}
+
+// ExpireOrders cancels the GTD orders whose expiry is due and, when maxAge is
+// positive, the orders that have been in the book for longer than maxAge. An order
+// that cannot be cancelled, because it was filled or cancelled in the meantime, is
+// logged and skipped so the others are still expired. It returns how many orders
+// were cancelled.
+func (s Service) ExpireOrders(now time.Time, maxAge time.Duration) (int, error) {
+	var staleBefore *time.Time
+	if maxAge > 0 {
+		t := now.Add(-maxAge)
+		staleBefore = &t
+	}
+
+	orders, err := s.Repo.ListOrdersToExpire(now, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, order := range orders {
+		if _, inEffect, err := order.StatusTransition(models.CANCEL); err != nil || inEffect {
+			continue
+		}
+
+		reason := models.CloseReasonStale
+		if order.ExpiresAt != nil && !order.ExpiresAt.After(now) {
+			reason = models.CloseReasonExpired
+		}
+
+		if _, err := s.Engine.UpdateStatus(order.Id.String(), models.CANCEL, reason); err != nil {
+			log.Printf("Error expiring order %s: %v\n", order.Id, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
 }
 
 func (s Service) GetClientById(id string) (models.ClientDtoOutput, error) {
@@ -148,10 +561,371 @@ func (s Service) GetClientById(id string) (models.ClientDtoOutput, error) {
 		return models.ClientDtoOutput{}, err
 	}
 
-	return models.ClientDtoOutput{
-		Id:         client.Id,
-		BalanceBRL: client.BalanceBRL,
-		BalanceBT:  client.BalanceBT,
-		Score:      client.Score,
+	return models.NewClientDtoOutput(client), nil
+}
+
+// CreateClient registers a client with the given opening balances and score.
+func (s Service) CreateClient(input models.ClientDtoInput) (models.ClientDtoOutput, error) {
+	if input.BalanceBRL.IsNegative() || input.BalanceBT.IsNegative() {
+		return models.ClientDtoOutput{}, models.ErrorInvalidBalance
+	}
+
+	if !models.HasPrecision(input.BalanceBRL, models.BRLPrecision) {
+		return models.ClientDtoOutput{}, models.ErrorInvalidBalanceBRLPrecision
+	}
+
+	if !models.HasPrecision(input.BalanceBT, models.BTPrecision) {
+		return models.ClientDtoOutput{}, models.ErrorInvalidBalanceBTPrecision
+	}
+
+	if input.Score < models.MinScore || input.Score > models.MaxScore {
+		return models.ClientDtoOutput{}, models.ErrorInvalidScore
+	}
+
+	id := uuid.New()
+	client, err := s.Repo.CreateClient(models.Client{
+		Id:        id,
+		Score:     input.Score,
+		CreatedAt: time.Now(),
+		Balances: []models.Balance{
+			{ClientId: id, Asset: models.AssetBRL, Available: input.BalanceBRL},
+			{ClientId: id, Asset: models.AssetBT, Available: input.BalanceBT},
+		},
+	})
+	if err != nil {
+		return models.ClientDtoOutput{}, err
+	}
+
+	return models.NewClientDtoOutput(client), nil
+}
+
+// validatePage fills the defaults of a zero page or page size and checks the range of both.
+func validatePage(page models.Pagination) (models.Pagination, error) {
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.PageSize == 0 {
+		page.PageSize = models.DefaultPageSize
+	}
+
+	if page.Page < 1 || page.PageSize < 1 || page.PageSize > models.MaxPageSize {
+		return models.Pagination{}, models.ErrorInvalidPagination
+	}
+
+	return page, nil
+}
+
+// ListClients returns one page of the registered clients. A zero page or page size
+// falls back to the first page and models.DefaultPageSize.
+func (s Service) ListClients(page models.Pagination) (models.ClientPageDtoOutput, error) {
+	page, err := validatePage(page)
+	if err != nil {
+		return models.ClientPageDtoOutput{}, err
+	}
+
+	clients, total, err := s.Repo.ListClients(page)
+	if err != nil {
+		return models.ClientPageDtoOutput{}, err
+	}
+
+	result := models.ClientPageDtoOutput{
+		Clients:  []models.ClientDtoOutput{},
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Total:    total,
+	}
+	for _, c := range clients {
+		result.Clients = append(result.Clients, models.NewClientDtoOutput(c))
+	}
+
+	return result, nil
+}
+
+// UpdateClient changes the score of a client.
+func (s Service) UpdateClient(id string, update models.ClientUpdateDtoInput) (models.ClientDtoOutput, error) {
+	if update.Score == nil {
+		return models.ClientDtoOutput{}, models.ErrorEmptyClientUpdate
+	}
+
+	if *update.Score < models.MinScore || *update.Score > models.MaxScore {
+		return models.ClientDtoOutput{}, models.ErrorInvalidScore
+	}
+
+	client, err := s.Repo.UpdateClientScore(id, *update.Score)
+	if err != nil {
+		return models.ClientDtoOutput{}, err
+	}
+
+	return models.NewClientDtoOutput(client), nil
+}
+
+// validateMovement checks the asset and the amount of a deposit or a withdrawal. Only
+// assets traded in a listed market can be moved.
+func (s Service) validateMovement(movement models.LedgerMovementDtoInput) error {
+	markets, err := s.Repo.ListMarkets()
+	if err != nil {
+		return err
+	}
+
+	listed := false
+	for _, market := range markets {
+		listed = listed || market.BaseAsset == movement.Asset || market.QuoteAsset == movement.Asset
+	}
+	if !listed {
+		return models.ErrorInvalidAsset
+	}
+
+	if !movement.Amount.IsPositive() {
+		return models.ErrorInvalidAmount
+	}
+
+	if !models.HasPrecision(movement.Amount, models.AssetPrecision(movement.Asset)) {
+		return models.ErrorInvalidAmountPrecision
+	}
+
+	return nil
+}
+
+// Deposit credits the available balance of the client and returns the journal
+// recorded in the ledger.
+func (s Service) Deposit(clientId string, movement models.LedgerMovementDtoInput) (models.Journal, error) {
+	if err := s.validateMovement(movement); err != nil {
+		return models.Journal{}, err
+	}
+
+	return s.Repo.Deposit(clientId, movement.Asset, movement.Amount)
+}
+
+// RequestWithdrawal opens a withdrawal of the client, holding its amount from the
+// available balance until it is sent or rejected. Balance held by orders cannot be withdrawn.
+// Trades in markets whose sizes are finer than the precision of the asset can leave
+// fractions of it in the balance, so the whole available balance can always be
+// withdrawn, whatever its precision.
+func (s Service) RequestWithdrawal(clientId string, movement models.LedgerMovementDtoInput) (models.Withdrawal, error) {
+	err := s.validateMovement(movement)
+	if errors.Is(err, models.ErrorInvalidAmountPrecision) {
+		client, clientErr := s.Repo.GetClientById(clientId)
+		if clientErr != nil {
+			return models.Withdrawal{}, clientErr
+		}
+		if client.Available(movement.Asset).Equal(movement.Amount) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+
+	return s.Repo.RequestWithdrawal(clientId, movement.Asset, movement.Amount)
+}
+
+// ListWithdrawals returns one page of the withdrawals in the given status, or of the
+// pending ones (REQUESTED and APPROVED) when no status is informed.
+func (s Service) ListWithdrawals(status string, page models.Pagination) (models.WithdrawalPageDtoOutput, error) {
+	statuses := []string{models.WithdrawalRequested, models.WithdrawalApproved}
+	switch status {
+	case "":
+	case models.WithdrawalRequested, models.WithdrawalApproved, models.WithdrawalSent, models.WithdrawalRejected:
+		statuses = []string{status}
+	default:
+		return models.WithdrawalPageDtoOutput{}, models.ErrorInvalidWithdrawalStatus
+	}
+
+	page, err := validatePage(page)
+	if err != nil {
+		return models.WithdrawalPageDtoOutput{}, err
+	}
+
+	withdrawals, total, err := s.Repo.ListWithdrawals(statuses, page)
+	if err != nil {
+		return models.WithdrawalPageDtoOutput{}, err
+	}
+
+	return models.WithdrawalPageDtoOutput{
+		Withdrawals: withdrawals,
+		Page:        page.Page,
+		PageSize:    page.PageSize,
+		Total:       total,
 	}, nil
 }
+
+// ApproveWithdrawal records the approval of the operator. Above the approval threshold
+// of the asset the withdrawal is only APPROVED after a second operator approves it.
+func (s Service) ApproveWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error) {
+	return s.reviewWithdrawal(id, func(w models.Withdrawal) (models.Withdrawal, error) {
+		return w.Approve(action.OperatorId)
+	})
+}
+
+// RejectWithdrawal closes a withdrawal that was not sent yet, giving the held amount back to the client.
+func (s Service) RejectWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error) {
+	return s.reviewWithdrawal(id, func(w models.Withdrawal) (models.Withdrawal, error) {
+		return w.Reject(action.OperatorId)
+	})
+}
+
+// SendWithdrawal records that an approved withdrawal left the exchange.
+func (s Service) SendWithdrawal(id string, action models.WithdrawalActionDtoInput) (models.Withdrawal, error) {
+	return s.reviewWithdrawal(id, func(w models.Withdrawal) (models.Withdrawal, error) {
+		return w.Send(action.OperatorId)
+	})
+}
+
+// reviewWithdrawal applies the review to the current state of the withdrawal and stores it.
+func (s Service) reviewWithdrawal(id string, review func(models.Withdrawal) (models.Withdrawal, error)) (models.Withdrawal, error) {
+	withdrawal, err := s.Repo.GetWithdrawalById(id)
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+
+	updated, err := review(withdrawal)
+	if err != nil {
+		return models.Withdrawal{}, err
+	}
+
+	return s.Repo.UpdateWithdrawal(withdrawal, updated)
+}
+
+// ListClientLedger returns one page of the ledger entries of the client, newest first.
+func (s Service) ListClientLedger(clientId string, page models.Pagination) (models.LedgerPageDtoOutput, error) {
+	page, err := validatePage(page)
+	if err != nil {
+		return models.LedgerPageDtoOutput{}, err
+	}
+
+	if _, err := s.Repo.GetClientById(clientId); err != nil {
+		return models.LedgerPageDtoOutput{}, err
+	}
+
+	entries, total, err := s.Repo.ListLedgerEntries(clientId, page)
+	if err != nil {
+		return models.LedgerPageDtoOutput{}, err
+	}
+
+	return models.LedgerPageDtoOutput{
+		Entries:  entries,
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Total:    total,
+	}, nil
+}
+
+// ReconcileLedger returns the client balances that differ from their ledger accounts.
+// An empty list means every balance is backed by the ledger.
+func (s Service) ReconcileLedger() ([]models.LedgerMismatch, error) {
+	return s.Repo.ReconcileLedger()
+}
+
+// GetBook returns the bid and ask price levels of the book of the market, or of
+// models.DefaultMarket when none is informed, best prices first.
+func (s Service) GetBook(market string, depth int) (models.BookDtoOutput, error) {
+	if depth < 1 || depth > models.MaxBookDepth {
+		return models.BookDtoOutput{}, models.ErrorInvalidBookDepth
+	}
+
+	if market == "" {
+		market = models.DefaultMarket
+	}
+
+	if _, err := s.market(market); err != nil {
+		return models.BookDtoOutput{}, err
+	}
+
+	return s.Engine.Book(market, depth)
+}
+
+// market returns the listed market with the given symbol, or ErrorInvalidMarket.
+func (s Service) market(symbol string) (models.Market, error) {
+	market, err := s.Repo.GetMarket(symbol)
+	if errors.Is(err, models.ErrorNotFound) {
+		return models.Market{}, models.ErrorInvalidMarket
+	}
+	if err != nil {
+		return models.Market{}, err
+	}
+
+	return market, nil
+}
+
+// CreateMarket lists a trading pair. Both assets are uppercase codes, and the new
+// ones can be deposited once the market is listed.
+func (s Service) CreateMarket(input models.MarketDtoInput) (models.Market, error) {
+	if !models.ValidAsset(input.BaseAsset) || !models.ValidAsset(input.QuoteAsset) || input.BaseAsset == input.QuoteAsset {
+		return models.Market{}, models.ErrorInvalidMarketAsset
+	}
+
+	if !input.TickSize.IsPositive() || !input.LotSize.IsPositive() || input.MinNotional.IsNegative() {
+		return models.Market{}, models.ErrorInvalidMarketSizes
+	}
+
+	// The notional limits of the score bands are set per quote asset.
+	if !s.Policy.Covers(input.QuoteAsset) {
+		return models.Market{}, models.ErrorInvalidMarketQuoteAsset
+	}
+
+	symbol := models.MarketSymbol(input.BaseAsset, input.QuoteAsset)
+	_, err := s.Repo.GetMarket(symbol)
+	if err == nil {
+		return models.Market{}, models.ErrorMarketAlreadyListed
+	}
+	if !errors.Is(err, models.ErrorNotFound) {
+		return models.Market{}, err
+	}
+
+	return s.Repo.CreateMarket(models.Market{
+		Symbol:      symbol,
+		BaseAsset:   input.BaseAsset,
+		QuoteAsset:  input.QuoteAsset,
+		TickSize:    input.TickSize,
+		LotSize:     input.LotSize,
+		MinNotional: input.MinNotional,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func (s Service) ListMarkets() ([]models.Market, error) {
+	return s.Repo.ListMarkets()
+}
+
+func (s Service) ListTrades(filter models.TradeFilter) ([]models.TradeDtoOutput, error) {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return []models.TradeDtoOutput{}, models.ErrorInvalidTimeRange
+	}
+
+	trades, err := s.Repo.ListTrades(filter)
+	if err != nil {
+		return []models.TradeDtoOutput{}, err
+	}
+
+	result := []models.TradeDtoOutput{}
+	for _, t := range trades {
+		result = append(result, models.TradeDtoOutput{
+			Id:           t.Id,
+			Market:       t.Market,
+			BuyOrderId:   t.BuyOrderId,
+			SellOrderId:  t.SellOrderId,
+			BuyerId:      t.BuyerId,
+			SellerId:     t.SellerId,
+			MakerOrderId: t.MakerOrderId,
+			TakerOrderId: t.TakerOrderId,
+			TakerSide:    models.TranslateTypeOrder(t.TakerSide),
+			Price:        t.Price,
+			Quantity:     t.Quantity,
+			Notional:     t.Notional,
+			BuyerFee:     t.BuyerFee,
+			SellerFee:    t.SellerFee,
+			CreatedAt:    t.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (s Service) ListClientTrades(clientId string, filter models.TradeFilter) ([]models.TradeDtoOutput, error) {
+	if _, err := s.Repo.GetClientById(clientId); err != nil {
+		return []models.TradeDtoOutput{}, err
+	}
+
+	filter.ClientId = clientId
+	return s.ListTrades(filter)
+}
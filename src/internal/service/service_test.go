@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,64 +28,215 @@ func (m *MockRepo) CreateOrder(order models.Orders) (models.Orders, error) {
 	return args.Get(0).(models.Orders), args.Error(1)
 }
 
+func (m *MockRepo) CreateOrders(orders []models.Orders) (int, error) {
+	args := m.Called(orders)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateClient(client models.Client) (models.Client, error) {
+	args := m.Called(client)
+	return args.Get(0).(models.Client), args.Error(1)
+}
+
+func (m *MockRepo) ListClients(page models.Pagination) ([]models.Client, int64, error) {
+	args := m.Called(page)
+	return args.Get(0).([]models.Client), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) UpdateClientScore(id string, score int) (models.Client, error) {
+	args := m.Called(id, score)
+	return args.Get(0).(models.Client), args.Error(1)
+}
+
+func (m *MockRepo) Deposit(clientId string, asset string, amount decimal.Decimal) (models.Journal, error) {
+	args := m.Called(clientId, asset, amount)
+	return args.Get(0).(models.Journal), args.Error(1)
+}
+
+func (m *MockRepo) RequestWithdrawal(clientId string, asset string, amount decimal.Decimal) (models.Withdrawal, error) {
+	args := m.Called(clientId, asset, amount)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) GetWithdrawalById(id string) (models.Withdrawal, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) ListWithdrawals(statuses []string, page models.Pagination) ([]models.Withdrawal, int64, error) {
+	args := m.Called(statuses, page)
+	return args.Get(0).([]models.Withdrawal), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) UpdateWithdrawal(previous, updated models.Withdrawal) (models.Withdrawal, error) {
+	args := m.Called(previous, updated)
+	return args.Get(0).(models.Withdrawal), args.Error(1)
+}
+
+func (m *MockRepo) ListLedgerEntries(clientId string, page models.Pagination) ([]models.LedgerEntry, int64, error) {
+	args := m.Called(clientId, page)
+	return args.Get(0).([]models.LedgerEntry), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) ReconcileLedger() ([]models.LedgerMismatch, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LedgerMismatch), args.Error(1)
+}
+
 func (m *MockRepo) GetClientById(id string) (models.Client, error) {
 	args := m.Called(id)
 	return args.Get(0).(models.Client), args.Error(1)
 }
 
-func (m *MockRepo) FindMatchOrderToSell(order models.Orders) (models.Orders, error) {
-	args := m.Called(order)
-	return args.Get(0).(models.Orders), args.Error(1)
+func (m *MockRepo) ListRestingOrders() ([]models.Orders, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListPendingStopOrders() ([]models.Orders, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListLastPrices() (map[string]decimal.Decimal, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]decimal.Decimal), args.Error(1)
 }
 
-func (m *MockRepo) FindMatchOrderToBuy(order models.Orders) (models.Orders, error) {
+func (m *MockRepo) TriggerOrder(order models.Orders) (models.Orders, error) {
 	args := m.Called(order)
 	return args.Get(0).(models.Orders), args.Error(1)
 }
 
-func (m *MockRepo) MakeTransactionSell(buy models.Orders, sell models.Orders) error {
-	args := m.Called(buy, sell)
+func (m *MockRepo) MakeTransactionSell(buy models.Orders, sell models.Orders, quantity decimal.Decimal) error {
+	args := m.Called(buy, sell, quantity)
 	return args.Error(0)
 }
 
-func (m *MockRepo) MakeTransactionBuy(buy models.Orders, sell models.Orders) error {
-	args := m.Called(buy, sell)
+func (m *MockRepo) MakeTransactionBuy(buy models.Orders, sell models.Orders, quantity decimal.Decimal) error {
+	args := m.Called(buy, sell, quantity)
 	return args.Error(0)
 }
 
+func (m *MockRepo) AmendOrder(order models.Orders) (models.Orders, error) {
+	args := m.Called(order)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error) {
+	args := m.Called(clientId, filter, reason)
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CountOpenOrders(clientId string) (int64, error) {
+	args := m.Called(clientId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRepo) GetOrderById(id string) (models.Orders, error) {
 	args := m.Called(id)
 	return args.Get(0).(models.Orders), args.Error(1)
 }
 
-func (m *MockRepo) UpdateStatusOrder(status int, orderId string) (models.Orders, error) {
-	args := m.Called(status, orderId)
+func (m *MockRepo) ListTrades(filter models.TradeFilter) ([]models.Trade, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Trade), args.Error(1)
+}
+
+func (m *MockRepo) UpdateStatusOrder(status int, orderId string, reason string) (models.Orders, error) {
+	args := m.Called(status, orderId, reason)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) ListOrdersToExpire(now time.Time, staleBefore *time.Time) ([]models.Orders, error) {
+	args := m.Called(now, staleBefore)
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockRepo) CreateMarket(market models.Market) (models.Market, error) {
+	args := m.Called(market)
+	return args.Get(0).(models.Market), args.Error(1)
+}
+
+func (m *MockRepo) GetMarket(symbol string) (models.Market, error) {
+	args := m.Called(symbol)
+	return args.Get(0).(models.Market), args.Error(1)
+}
+
+func (m *MockRepo) ListMarkets() ([]models.Market, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Market), args.Error(1)
+}
+
+type MockEngine struct {
+	mock.Mock
+}
+
+func (m *MockEngine) Submit(order models.Orders) (models.Orders, error) {
+	args := m.Called(order)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockEngine) SubmitBatch(orders []models.Orders) ([]models.Orders, int, error) {
+	args := m.Called(orders)
+	return args.Get(0).([]models.Orders), args.Int(1), args.Error(2)
+}
+
+func (m *MockEngine) UpdateStatus(orderId string, status int, reason string) (models.Orders, error) {
+	args := m.Called(orderId, status, reason)
+	return args.Get(0).(models.Orders), args.Error(1)
+}
+
+func (m *MockEngine) Amend(orderId string, price, quantity decimal.Decimal) (models.Orders, error) {
+	args := m.Called(orderId, price, quantity)
 	return args.Get(0).(models.Orders), args.Error(1)
 }
 
+func (m *MockEngine) CancelClientOrders(clientId string, filter models.OrderCancelFilter, reason string) ([]models.Orders, error) {
+	args := m.Called(clientId, filter, reason)
+	return args.Get(0).([]models.Orders), args.Error(1)
+}
+
+func (m *MockEngine) Book(market string, depth int) (models.BookDtoOutput, error) {
+	args := m.Called(market, depth)
+	return args.Get(0).(models.BookDtoOutput), args.Error(1)
+}
+
+// balances returns the available BRL and BT balances of a client.
+func balances(brl, bt decimal.Decimal) []models.Balance {
+	return []models.Balance{
+		{Asset: models.AssetBRL, Available: brl},
+		{Asset: models.AssetBT, Available: bt},
+	}
+}
+
+// btBRL is the default market, as listed in the database.
+var btBRL = models.DefaultMarkets[0]
+
 func TestCreateOrder(t *testing.T) {
 	mockRepo := new(MockRepo)
-	svc := service.NewService(mockRepo)
+	mockEngine := new(MockEngine)
+	svc := service.NewService(mockRepo, mockEngine)
 
 	client := models.Client{
-		Id:         uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-		BalanceBRL: 12500,
-		BalanceBT:  8,
-		Score:      98,
+		Id:       uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		Balances: balances(decimal.NewFromFloat(12500), decimal.NewFromFloat(8)),
+		Score:    98,
 	}
 
 	t.Run("Must create an order successfully", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(2),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
-		mockRepo.On("CreateOrder", mock.Anything).Return(order, nil)
-		mockRepo.On("FindMatchOrderToBuy", mock.Anything).Return(models.Orders{}, models.ErrorNotFound)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.Anything).Return(order, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -91,16 +244,45 @@ func TestCreateOrder(t *testing.T) {
 		assert.NotEmpty(t, id)
 
 		mockRepo.AssertExpectations(t)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Must ignore the time priority sent by the client", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		early := time.Now().Add(-24 * time.Hour)
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(2),
+			Price:        decimal.NewFromInt(500),
+			OwnerOrderId: client.Id,
+			CreatedAt:    early,
+			PriorityAt:   &early,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.PriorityAt == nil && o.CreatedAt.After(early.Add(time.Hour))
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
 	})
 
 	t.Run("It should fail if the order is a purchase order and the customer's BRL balance is less than the amount in their account.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 25447,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(25447),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		order.OwnerOrderId = uuid.MustParse("a7402f4d-e180-4963-bcc7-e02371a39dca")
 
@@ -116,12 +298,12 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("It should fail if the order is a sell order and the customer's BT balance is less than the amount in their account.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     2,
-			Status:        1,
-			PriceOrderBT:  25447,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    2,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(25447),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		order.OwnerOrderId = uuid.MustParse("a7402f4d-e180-4963-bcc7-e02371a39dca")
 
@@ -135,14 +317,77 @@ func TestCreateOrder(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Should fail if the order is created with a closed status", func(t *testing.T) {
+		order := models.Orders{
+			TypeOrder:    1,
+			Status:       models.CANCEL,
+			Quantity:     decimal.NewFromFloat(1),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidCreateOrderStatus)
+		assert.Empty(t, id)
+	})
+
+	t.Run("It should fail if the notional of a purchase order (price times quantity) is greater than the customer's BRL balance.", func(t *testing.T) {
+		order := models.Orders{
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(30),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+		assert.Empty(t, id)
+	})
+
+	t.Run("Should fail if the order price is not a multiple of the BT-BRL tick size (centavos)", func(t *testing.T) {
+		order := models.Orders{
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.RequireFromString("0.1"),
+			Price:        decimal.RequireFromString("100.005"),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidPriceTick)
+		assert.Empty(t, id)
+	})
+
+	t.Run("Should fail if the order quantity is not a multiple of the BT-BRL lot size (satoshis)", func(t *testing.T) {
+		order := models.Orders{
+			TypeOrder:    2,
+			Status:       1,
+			Quantity:     decimal.RequireFromString("0.000000001"),
+			Price:        decimal.RequireFromString("100"),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidQuantityLot)
+		assert.Empty(t, id)
+	})
+
 	t.Run("Should fail if a customer is not found in the database related to that order.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		order.OwnerOrderId = uuid.MustParse("a7402f4d-e180-4963-bcc7-e02371a39dca")
 
@@ -158,14 +403,15 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("Should fail if the order type is less than 1.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     -5,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    -5,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -177,15 +423,16 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("Should fail if the order type is greater than 2.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     7,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    7,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -197,14 +444,15 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("Should fail if the order status is less than 1.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        -1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       -1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -216,14 +464,15 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("Should fail if the order status is greater than 4.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        7,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       7,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -235,14 +484,15 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("Should fail if the order price in BRL is less than or equal to 0.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: -5.40,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(-5.40),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -252,16 +502,17 @@ func TestCreateOrder(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("Should fail if the order price in BT is less than or equal to 0.", func(t *testing.T) {
+	t.Run("Should fail if the order quantity is less than or equal to 0.", func(t *testing.T) {
 		order := models.Orders{
-			Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  -750,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+			Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(-750),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
 		}
 		mockRepo.On("GetClientById", order.OwnerOrderId.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
 
 		id, err := svc.CreateOrder(order)
 
@@ -273,242 +524,2328 @@ func TestCreateOrder(t *testing.T) {
 
 }
 
-func TestListOrders(t *testing.T) {
-	mockRepo := new(MockRepo)
-	svc := service.NewService(mockRepo)
-
-	orders := []models.Orders{
-		{
-			Id:            uuid.New(),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  1,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.New(),
-		},
-		{
-			Id:            uuid.New(),
-			TypeOrder:     2,
-			Status:        1,
-			PriceOrderBT:  2,
-			PriceOrderBRL: 6000,
-			OwnerOrderId:  uuid.New(),
-		},
-		{
-			Id:            uuid.New(),
-			TypeOrder:     1,
-			Status:        2,
-			PriceOrderBT:  4,
-			PriceOrderBRL: 2500,
-			OwnerOrderId:  uuid.New(),
-		},
-		{
-			Id:            uuid.New(),
-			TypeOrder:     1,
-			Status:        3,
-			PriceOrderBT:  3,
-			PriceOrderBRL: 1520,
-			OwnerOrderId:  uuid.New(),
-		},
-		{
-			Id:            uuid.New(),
-			TypeOrder:     2,
-			Status:        4,
-			PriceOrderBT:  6,
-			PriceOrderBRL: 652,
-			OwnerOrderId:  uuid.New(),
-		},
+func TestCreateOrderBatch(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("2c7e9a51-4d8b-4f36-a1e0-9b5c3d7f2e84"),
+		Balances: balances(decimal.NewFromInt(1000), decimal.NewFromInt(1)),
+	}
+	buy := models.Orders{
+		OwnerOrderId: client.Id,
+		TypeOrder:    models.BUY,
+		Status:       models.OPEN,
+		Quantity:     decimal.NewFromInt(6),
+		Price:        decimal.NewFromInt(100),
 	}
 
-	t.Run("Must return an array with all registered orders", func(t *testing.T) {
-		mockRepo.On("ListOrders").Return(orders, nil)
-
-		res, err := svc.ListOrders()
+	t.Run("Should fail if the batch is empty", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
 
-		assert.NoError(t, err)
-		assert.NotEmpty(t, res)
-		assert.Len(t, res, 5)
+		res, err := svc.CreateOrderBatch(models.OrderBatchDtoInput{})
 
-		mockRepo.AssertExpectations(t)
+		assert.ErrorIs(t, err, models.ErrorInvalidBatchSize)
+		assert.Empty(t, res)
 	})
-}
 
-func TestUpdateStatusOrder(t *testing.T) {
-	mockRepo := new(MockRepo)
-	svc := service.NewService(mockRepo)
+	t.Run("Must return the result of each order on its own", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-	order := models.Orders{
-		Id:            uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
-		TypeOrder:     1,
-		Status:        1,
-		PriceOrderBT:  100,
-		PriceOrderBRL: 500,
-		OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-	}
+		invalid := buy
+		invalid.Quantity = decimal.Zero
+		created := buy
+		created.Id = uuid.New()
 
-	t.Run("Should fail if the order status is less than 1", func(t *testing.T) {
-		res, err := svc.UpdateStatusOrder(-5, order.Id.String())
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.Anything).Return(created, nil).Once()
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "INVALID_INPUT: invalid status", err.Error())
+		res, err := svc.CreateOrderBatch(models.OrderBatchDtoInput{Orders: []models.Orders{buy, invalid}})
 
-		mockRepo.AssertExpectations(t)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, created.Id, *res[0].Id)
+		assert.Equal(t, models.StatusCodeCreated, res[0].Status)
+		assert.Nil(t, res[1].Id)
+		assert.Equal(t, models.ErrorInvalidQuantityOrder.Message, res[1].Error)
+		mockEngine.AssertExpectations(t)
 	})
 
-	t.Run("Should fail if the order status is greater than 4", func(t *testing.T) {
-		res, err := svc.UpdateStatusOrder(7, order.Id.String())
+	t.Run("Should reject an all-or-nothing batch the client cannot hold as a whole", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "INVALID_INPUT: invalid status", err.Error())
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
 
-		mockRepo.AssertExpectations(t)
-	})
+		res, err := svc.CreateOrderBatch(models.OrderBatchDtoInput{Orders: []models.Orders{buy, buy}, AllOrNothing: true})
 
-	t.Run("Should fail if the order cannot be found", func(t *testing.T) {
-		order.Id = uuid.MustParse("a7402f4d-e180-4963-bcc7-e02371a39dca")
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, models.ErrorBatchAborted.Message, res[0].Error)
+		assert.Equal(t, models.ErrorInsufficientBalance.Message, res[1].Error)
+		mockEngine.AssertNotCalled(t, "SubmitBatch", mock.Anything)
+	})
 
-		mockRepo.On("GetOrderById", "a7402f4d-e180-4963-bcc7-e02371a39dca").Return(models.Orders{}, errors.New("record not found"))
-		res, err := svc.UpdateStatusOrder(2, order.Id.String())
+	t.Run("Must submit an all-or-nothing batch to the engine at once", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "NOT_FOUND: record not found", err.Error())
-	})
+		sell := buy
+		sell.TypeOrder = models.SELL
+		sell.Quantity = decimal.NewFromInt(1)
+		created := []models.Orders{{Id: uuid.New()}, {Id: uuid.New()}}
 
-	t.Run("Should fail if the order is already done", func(t *testing.T) {
-		order.Status = 3
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("SubmitBatch", mock.MatchedBy(func(orders []models.Orders) bool {
+			return len(orders) == 2 && orders[0].Id != uuid.Nil && orders[1].Id != uuid.Nil
+		})).Return(created, -1, nil).Once()
 
-		mockRepo.On("GetOrderById", "b794a8dc-415e-435c-8a44-551cf8244e68").Return(order, nil)
-		res, err := svc.UpdateStatusOrder(2, "b794a8dc-415e-435c-8a44-551cf8244e68")
+		res, err := svc.CreateOrderBatch(models.OrderBatchDtoInput{Orders: []models.Orders{buy, sell}, AllOrNothing: true})
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "INVALID_INPUT: invalid update, this order was done", err.Error())
+		assert.NoError(t, err)
+		assert.Equal(t, created[0].Id, *res[0].Id)
+		assert.Equal(t, created[1].Id, *res[1].Id)
+		mockEngine.AssertExpectations(t)
 	})
+}
 
-	t.Run("Should fail if the order is already canceled", func(t *testing.T) {
-		orderT := models.Orders{
-			Id:            uuid.MustParse("096338a2-8bc6-4d4c-a8e0-d395981b7031"),
-			TypeOrder:     1,
-			Status:        4,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-		}
+func TestCreateOrderScoreLimits(t *testing.T) {
+	order := models.Orders{
+		OwnerOrderId: uuid.MustParse("7d3f9b2a-6c1e-4a8d-b5f0-2e9c8a1d4b67"),
+		TypeOrder:    models.BUY,
+		Status:       models.OPEN,
+		Quantity:     decimal.NewFromInt(2),
+		Price:        decimal.NewFromInt(60000),
+	}
+	client := models.Client{
+		Id:       order.OwnerOrderId,
+		Balances: balances(decimal.NewFromInt(500000), decimal.NewFromInt(10)),
+		Score:    60,
+	}
 
-		mockRepo.On("GetOrderById", "096338a2-8bc6-4d4c-a8e0-d395981b7031").Return(orderT, nil)
-		res, err := svc.UpdateStatusOrder(2, "096338a2-8bc6-4d4c-a8e0-d395981b7031")
+	t.Run("Should fail if the notional is above the limit of the score band", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "INVALID_INPUT: invalid update, this order was cancel", err.Error())
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorOrderNotionalLimit)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
 	})
 
-	t.Run("Should return 'status in effect for this order' if the status sent is the same as the one already on the order", func(t *testing.T) {
-		orderT := models.Orders{
-			Id:            uuid.MustParse("f5ca0998-a0c1-4e6a-bdc9-f70521f9154f"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-		}
+	t.Run("Must accept the same order from a client of a higher band", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-		mockRepo.On("GetOrderById", "f5ca0998-a0c1-4e6a-bdc9-f70521f9154f").Return(orderT, nil)
-		res, err := svc.UpdateStatusOrder(1, "f5ca0998-a0c1-4e6a-bdc9-f70521f9154f")
+		trusted := client
+		trusted.Score = 75
+		mockRepo.On("GetClientById", client.Id.String()).Return(trusted, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.NotionalLimit.Equal(decimal.NewFromInt(250000))
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, res)
-		assert.Equal(t, "status in effect for this order", res)
+		mockEngine.AssertExpectations(t)
 	})
 
-	t.Run("Should fail if an order is waiting and an attempt is made to change the status to something other than OPEN or CANCEL", func(t *testing.T) {
-		orderT := models.Orders{
-			Id:            uuid.MustParse("f9c1554a-3fde-4619-8e0e-c0b56a752ede"),
-			TypeOrder:     1,
-			Status:        2,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+	t.Run("Should fail if the notional is above the limit of the score band in the quote asset of the market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		ethBT := models.Market{
+			Symbol:     "ETH-BT",
+			BaseAsset:  "ETH",
+			QuoteAsset: models.AssetBT,
+			TickSize:   decimal.RequireFromString("0.00001"),
+			LotSize:    decimal.RequireFromString("0.001"),
+		}
+		// 10 * 0.05 = 0.5 BT, above the 0.3 BT of the band.
+		inBT := models.Orders{
+			OwnerOrderId: client.Id,
+			Market:       ethBT.Symbol,
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(10),
+			Price:        decimal.RequireFromString("0.05"),
 		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBT.Symbol).Return(ethBT, nil)
 
-		mockRepo.On("GetOrderById", "f9c1554a-3fde-4619-8e0e-c0b56a752ede").Return(orderT, nil)
-		res, err := svc.UpdateStatusOrder(3, "f9c1554a-3fde-4619-8e0e-c0b56a752ede")
+		_, err := svc.CreateOrder(inBT)
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "INVALID_INPUT: invalid update, An order waiting only change status to OPEN or CANCEL", err.Error())
+		assert.ErrorIs(t, err, models.ErrorOrderNotionalLimit)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
 	})
 
-	t.Run("Should update successfully", func(t *testing.T) {
-		orderT := models.Orders{
-			Id:            uuid.MustParse("abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28"),
-			TypeOrder:     1,
-			Status:        1,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-		}
+	btcUSDT := models.Market{
+		Symbol:     "BTC-USDT",
+		BaseAsset:  "BTC",
+		QuoteAsset: models.AssetUSDT,
+		TickSize:   decimal.RequireFromString("0.01"),
+		LotSize:    decimal.RequireFromString("0.00001"),
+	}
+	inUSDT := order
+	inUSDT.Market = btcUSDT.Symbol
+	inUSDT.Quantity = decimal.RequireFromString("0.3")
+	inUSDT.Price = decimal.NewFromInt(60000)
+	owner := client
+	owner.Balances = []models.Balance{{Asset: models.AssetUSDT, Available: decimal.NewFromInt(50000)}}
+
+	t.Run("Should fail if the notional is above the USDT limit of the score band in a USDT-quoted market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		// 0.4 * 60000 = 24000 USDT, above the 20000 USDT of the band.
+		above := inUSDT
+		above.Quantity = decimal.RequireFromString("0.4")
+		mockRepo.On("GetClientById", client.Id.String()).Return(owner, nil)
+		mockRepo.On("GetMarket", btcUSDT.Symbol).Return(btcUSDT, nil)
+
+		_, err := svc.CreateOrder(above)
+
+		assert.ErrorIs(t, err, models.ErrorOrderNotionalLimit)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
 
-		orderF := models.Orders{
-			Id:            uuid.MustParse("abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28"),
-			TypeOrder:     1,
-			Status:        3,
-			PriceOrderBT:  100,
-			PriceOrderBRL: 500,
-			OwnerOrderId:  uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
-		}
+	t.Run("Must submit an order of a USDT-quoted market holding USDT", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-		mockRepo.On("GetOrderById", "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28").Return(orderT, nil)
-		mockRepo.On("UpdateStatusOrder", 3, "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28").Return(orderF, nil)
-		res, err := svc.UpdateStatusOrder(3, "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28")
+		mockRepo.On("GetClientById", client.Id.String()).Return(owner, nil)
+		mockRepo.On("GetMarket", btcUSDT.Symbol).Return(btcUSDT, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Market == btcUSDT.Symbol && o.HoldAsset() == models.AssetUSDT && o.NotionalLimit.Equal(decimal.NewFromInt(20000))
+		})).Return(inUSDT, nil).Once()
+
+		_, err := svc.CreateOrder(inUSDT)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, res)
-		expect := fmt.Sprintf("order %s updated", orderF.Id)
-		assert.Equal(t, expect, "order abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28 updated")
+		mockEngine.AssertExpectations(t)
 	})
-}
 
-func TestGetClientById(t *testing.T) {
-	mockRepo := new(MockRepo)
-	svc := service.NewService(mockRepo)
+	t.Run("Should fail if the score band has no limit for the quote asset of the market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
 
-	t.Run("Should fail if the client cannot be found", func(t *testing.T) {
-		client := models.Client{
-			Id:         uuid.MustParse("bc7e77eb-12d1-4e3a-b4af-8682302dc0b4"),
-			BalanceBRL: 12455,
-			BalanceBT:  14,
-			Score:      91,
-		}
-		mockRepo.On("GetClientById", client.Id.String()).Return(models.Client{}, errors.New("record not found"))
-		res, err := svc.GetClientById(client.Id.String())
+		btcDAI := btcUSDT
+		btcDAI.Symbol, btcDAI.QuoteAsset = "BTC-DAI", "DAI"
+		inDAI := inUSDT
+		inDAI.Market = btcDAI.Symbol
+		holder := owner
+		holder.Balances = []models.Balance{{Asset: "DAI", Available: decimal.NewFromInt(50000)}}
+		mockRepo.On("GetClientById", client.Id.String()).Return(holder, nil)
+		mockRepo.On("GetMarket", btcDAI.Symbol).Return(btcDAI, nil)
 
-		assert.Error(t, err)
-		assert.Empty(t, res)
-		assert.Equal(t, "record not found", err.Error())
+		_, err := svc.CreateOrder(inDAI)
+
+		assert.ErrorIs(t, err, models.ErrorNoNotionalLimit)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
 	})
 
-	t.Run("Must return the client successfully", func(t *testing.T) {
-		client := models.Client{
-			Id:         uuid.MustParse("0b20b052-abd2-4da8-ac7e-5632118be457"),
-			BalanceBRL: 12455,
-			BalanceBT:  14,
-			Score:      91,
-		}
+	t.Run("Should fail if the client reached the open orders limit of the score band", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		small := order
+		small.Price = decimal.NewFromInt(100)
 		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
-		res, err := svc.GetClientById(client.Id.String())
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(20), nil)
+
+		_, err := svc.CreateOrder(small)
+
+		assert.ErrorIs(t, err, models.ErrorOpenOrdersLimit)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Must not count orders that never rest in the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		ioc := order
+		ioc.Price = decimal.NewFromInt(100)
+		ioc.TimeInForce = models.IOC
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockEngine.On("Submit", mock.Anything).Return(ioc, nil).Once()
+
+		_, err := svc.CreateOrder(ioc)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "CountOpenOrders", mock.Anything)
+	})
+
+	t.Run("Should count the orders of an all-or-nothing batch towards the open orders limit", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		small := order
+		small.Price = decimal.NewFromInt(100)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(19), nil)
+
+		res, err := svc.CreateOrderBatch(models.OrderBatchDtoInput{Orders: []models.Orders{small, small}, AllOrNothing: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.ErrorBatchAborted.Message, res[0].Error)
+		assert.Equal(t, models.ErrorOpenOrdersLimit.Message, res[1].Error)
+		mockEngine.AssertNotCalled(t, "SubmitBatch", mock.Anything)
+	})
+}
+
+func TestCreateMarketOrder(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("5e0f5a3c-2a8e-4b4f-9b0e-8d1f0c6b7a21"),
+		Balances: balances(decimal.NewFromInt(500), decimal.NewFromInt(2)),
+		Score:    80,
+	}
+
+	t.Run("Must submit a market order without a price to the engine", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			OrderKind:    models.MARKET,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			Price:        decimal.NewFromInt(90),
+			OwnerOrderId: client.Id,
+		}
+
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Price.IsZero() && o.TimeInForce == models.GTC && o.Id != uuid.Nil
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Should reject a market order when the book cannot fill it", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			OrderKind:    models.MARKET,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(2),
+			OwnerOrderId: client.Id,
+		}
+
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockEngine.On("Submit", mock.Anything).Return(models.Orders{}, models.ErrorInsufficientLiquidity).Once()
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientLiquidity)
+		assert.Empty(t, id)
+	})
+
+	t.Run("Should fail if a market order is not created as OPEN", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.SELL,
+			OrderKind:    models.MARKET,
+			Status:       models.WAITING,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarketOrderStatus)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+}
+
+func TestCreateOrderTimeInForce(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("9a6b2f3e-41c7-4d8e-a0b5-7c2e9f1d3b46"),
+		Balances: balances(decimal.NewFromInt(100000), decimal.NewFromInt(5)),
+		Score:    75,
+	}
+
+	t.Run("Should fail if an IOC order is not created as OPEN", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			TimeInForce:  models.IOC,
+			Status:       models.WAITING,
+			Quantity:     decimal.NewFromInt(1),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidTimeInForceStatus)
+		assert.Empty(t, id)
+	})
+
+	t.Run("Should fail if a GTD order has no expiry in the future", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		past := time.Now().Add(-time.Minute)
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			TimeInForce:  models.GTD,
+			ExpiresAt:    &past,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidExpiresAt)
+		assert.Empty(t, id)
+	})
+
+	t.Run("Should fail if an expiry is sent for an order that is not GTD", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		future := time.Now().Add(time.Hour)
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			ExpiresAt:    &future,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorExpiresAtWithoutGTD)
+		assert.Empty(t, id)
+	})
+}
+
+func TestCreateStopOrder(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("c7e2a4b9-1f58-4d3a-9b6e-8d0f2a5c7e13"),
+		Balances: balances(decimal.NewFromInt(100000), decimal.NewFromInt(5)),
+		Score:    75,
+	}
+
+	t.Run("Should fail if a stop order has no stop price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			OrderKind:    models.STOP_LIMIT,
+			Price:        decimal.NewFromInt(1000),
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidStopPrice)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if an order that is not a stop order has a stop price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.BUY,
+			StopPrice:    decimal.NewFromInt(900),
+			Price:        decimal.NewFromInt(1000),
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorStopPriceWithoutStop)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if a stop order is not GTC or GTD", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.SELL,
+			OrderKind:    models.STOP_MARKET,
+			TimeInForce:  models.IOC,
+			StopPrice:    decimal.NewFromInt(900),
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidStopTimeInForce)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if the stop price is not a multiple of the tick size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.SELL,
+			OrderKind:    models.STOP_LIMIT,
+			StopPrice:    decimal.RequireFromString("900.005"),
+			Price:        decimal.NewFromInt(890),
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidStopPriceTick)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Must submit a stop-market order without a price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:    models.SELL,
+			OrderKind:    models.STOP_MARKET,
+			StopPrice:    decimal.NewFromInt(900),
+			Price:        decimal.NewFromInt(950),
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.OrderKind == models.STOP_MARKET && o.Price.IsZero() && o.StopPrice.Equal(decimal.NewFromInt(900))
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestCreateIcebergOrder(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("3b9e5d2a-7c41-4f08-a6e3-1d8c0b4f9a52"),
+		Balances: balances(decimal.NewFromInt(100000), decimal.NewFromInt(5)),
+		Score:    75,
+	}
+
+	t.Run("Should fail if the display quantity is not below the quantity", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:       models.SELL,
+			Price:           decimal.NewFromInt(1000),
+			Status:          models.OPEN,
+			Quantity:        decimal.NewFromInt(2),
+			DisplayQuantity: decimal.NewFromInt(2),
+			OwnerOrderId:    client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidDisplayQuantity)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if the display quantity is not a multiple of the lot size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:       models.SELL,
+			Price:           decimal.NewFromInt(1000),
+			Status:          models.OPEN,
+			Quantity:        decimal.NewFromInt(2),
+			DisplayQuantity: decimal.RequireFromString("0.000000005"),
+			OwnerOrderId:    client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidDisplayQuantityLot)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if an iceberg order cannot rest in the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:       models.BUY,
+			TimeInForce:     models.IOC,
+			Price:           decimal.NewFromInt(1000),
+			Status:          models.OPEN,
+			Quantity:        decimal.NewFromInt(2),
+			DisplayQuantity: decimal.NewFromInt(1),
+			OwnerOrderId:    client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		id, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidIcebergOrder)
+		assert.Empty(t, id)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Must submit an iceberg order with its display quantity", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			TypeOrder:       models.SELL,
+			Price:           decimal.NewFromInt(1000),
+			Status:          models.OPEN,
+			Quantity:        decimal.NewFromInt(2),
+			DisplayQuantity: decimal.RequireFromString("0.5"),
+			OwnerOrderId:    client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.IsIceberg() && o.DisplayQuantity.Equal(decimal.RequireFromString("0.5"))
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestCreateOrderMarkets(t *testing.T) {
+	ethBRL := models.Market{
+		Symbol:     "ETH-BRL",
+		BaseAsset:  "ETH",
+		QuoteAsset: models.AssetBRL,
+		TickSize:   decimal.RequireFromString("0.01"),
+		LotSize:    decimal.RequireFromString("0.0001"),
+	}
+	client := models.Client{
+		Id: uuid.MustParse("5d1c8e2a-7f34-4b9a-9c61-0e8b2d4f6a13"),
+		Balances: []models.Balance{
+			{Asset: models.AssetBRL, Available: decimal.NewFromInt(50000)},
+			{Asset: "ETH", Available: decimal.NewFromInt(2)},
+		},
+		Score: 80,
+	}
+
+	t.Run("Should fail if the market is not listed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       "ETH-USDT",
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(1),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", "ETH-USDT").Return(models.Market{}, models.ErrorNotFound)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarket)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should check the balance of the base asset of the market on a sale", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.SELL,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(3),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+	})
+
+	t.Run("Must submit the order in its market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.SELL,
+			Status:       models.OPEN,
+			Quantity:     decimal.NewFromInt(2),
+			Price:        decimal.NewFromInt(1000),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.MatchedBy(func(o models.Orders) bool {
+			return o.Market == ethBRL.Symbol && o.HoldAsset() == "ETH"
+		})).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestCreateOrderMarketRules(t *testing.T) {
+	ethBRL := models.Market{
+		Symbol:      "ETH-BRL",
+		BaseAsset:   "ETH",
+		QuoteAsset:  models.AssetBRL,
+		TickSize:    decimal.RequireFromString("0.50"),
+		LotSize:     decimal.RequireFromString("0.001"),
+		MinNotional: decimal.NewFromInt(10),
+	}
+	client := models.Client{
+		Id:       uuid.MustParse("3a9e7c15-2b4d-4f68-8e0a-6c1d5b7f9e24"),
+		Balances: []models.Balance{{Asset: models.AssetBRL, Available: decimal.NewFromInt(50000)}},
+		Score:    80,
+	}
+
+	t.Run("Must accept the precision of the market instead of the BT-BRL one", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		ethBT := models.Market{
+			Symbol:     "ETH-BT",
+			BaseAsset:  "ETH",
+			QuoteAsset: models.AssetBT,
+			TickSize:   decimal.RequireFromString("0.00001"),
+			LotSize:    decimal.RequireFromString("0.001"),
+		}
+		owner := client
+		owner.Balances = []models.Balance{{Asset: models.AssetBT, Available: decimal.NewFromInt(1)}}
+		order := models.Orders{
+			Market:       ethBT.Symbol,
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Price:        decimal.RequireFromString("0.05123"),
+			Quantity:     decimal.RequireFromString("1.5"),
+			OwnerOrderId: owner.Id,
+		}
+		mockRepo.On("GetClientById", owner.Id.String()).Return(owner, nil)
+		mockRepo.On("GetMarket", ethBT.Symbol).Return(ethBT, nil)
+		mockRepo.On("CountOpenOrders", owner.Id.String()).Return(int64(0), nil)
+		mockEngine.On("Submit", mock.Anything).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if the price is not a multiple of the tick size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Price:        decimal.RequireFromString("1000.25"),
+			Quantity:     decimal.RequireFromString("1"),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidPriceTick)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if the quantity is not a multiple of the lot size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Price:        decimal.RequireFromString("1000.50"),
+			Quantity:     decimal.RequireFromString("0.0015"),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidQuantityLot)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Should fail if the order value is below the minimum notional", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.BUY,
+			Status:       models.OPEN,
+			Price:        decimal.RequireFromString("1000"),
+			Quantity:     decimal.RequireFromString("0.009"),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+
+		_, err := svc.CreateOrder(order)
+
+		assert.ErrorIs(t, err, models.ErrorOrderBelowMinNotional)
+		mockEngine.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Must not hold market orders to the minimum notional", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		order := models.Orders{
+			Market:       ethBRL.Symbol,
+			TypeOrder:    models.BUY,
+			OrderKind:    models.MARKET,
+			Status:       models.OPEN,
+			Quantity:     decimal.RequireFromString("0.001"),
+			OwnerOrderId: client.Id,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", ethBRL.Symbol).Return(ethBRL, nil)
+		mockEngine.On("Submit", mock.Anything).Return(order, nil).Once()
+
+		_, err := svc.CreateOrder(order)
+
+		assert.NoError(t, err)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestCreateOcoOrder(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("6f1d3b8e-2a7c-4e95-b0d4-5c8a9e2f1b37"),
+		Balances: balances(decimal.NewFromInt(5000), decimal.NewFromInt(2)),
+		Score:    75,
+	}
+	takeProfit := models.Orders{
+		OwnerOrderId: client.Id,
+		TypeOrder:    models.SELL,
+		Status:       models.OPEN,
+		Quantity:     decimal.NewFromInt(1),
+		Price:        decimal.NewFromInt(1100),
+	}
+	stopLoss := models.Orders{
+		OwnerOrderId: client.Id,
+		TypeOrder:    models.SELL,
+		OrderKind:    models.STOP_MARKET,
+		Status:       models.OPEN,
+		Quantity:     decimal.NewFromInt(1),
+		StopPrice:    decimal.NewFromInt(900),
+	}
+
+	t.Run("Should fail if the group does not have exactly 2 orders", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		res, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{takeProfit}})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidOcoSize)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Should fail if the orders are not on the same side", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		buy := takeProfit
+		buy.TypeOrder = models.BUY
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+
+		_, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{stopLoss, buy}})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidOcoOrders)
+		mockEngine.AssertNotCalled(t, "SubmitBatch", mock.Anything)
+	})
+
+	t.Run("Should fail if an order cannot rest in the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		ioc := takeProfit
+		ioc.TimeInForce = models.IOC
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		_, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{ioc, stopLoss}})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidOcoOrderKind)
+		mockEngine.AssertNotCalled(t, "SubmitBatch", mock.Anything)
+	})
+
+	t.Run("Should fail if the client cannot hold the larger order", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		larger := stopLoss
+		larger.Quantity = decimal.RequireFromString("2.5")
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+
+		_, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{takeProfit, larger}})
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+		mockEngine.AssertNotCalled(t, "SubmitBatch", mock.Anything)
+	})
+
+	t.Run("Must accept orders that together hold more than the balance, as they share one hold", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		larger := stopLoss
+		larger.Quantity = decimal.RequireFromString("1.5")
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("SubmitBatch", mock.Anything).Return([]models.Orders{takeProfit, larger}, -1, nil).Once()
+
+		res, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{takeProfit, larger}})
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Must submit both orders linked to each other", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("CountOpenOrders", client.Id.String()).Return(int64(0), nil)
+		mockEngine.On("SubmitBatch", mock.MatchedBy(func(orders []models.Orders) bool {
+			return len(orders) == 2 &&
+				*orders[0].LinkedOrderId == orders[1].Id &&
+				*orders[1].LinkedOrderId == orders[0].Id
+		})).Return([]models.Orders{takeProfit, stopLoss}, -1, nil).Once()
+
+		res, err := svc.CreateOcoOrder(models.OrderOcoDtoInput{Orders: []models.Orders{takeProfit, stopLoss}})
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestListOrders(t *testing.T) {
+	mockRepo := new(MockRepo)
+	svc := service.NewService(mockRepo, new(MockEngine))
+
+	orders := []models.Orders{
+		{
+			Id:           uuid.New(),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(1),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.New(),
+		},
+		{
+			Id:           uuid.New(),
+			TypeOrder:    2,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(2),
+			Price:        decimal.NewFromFloat(6000),
+			OwnerOrderId: uuid.New(),
+		},
+		{
+			Id:           uuid.New(),
+			TypeOrder:    1,
+			Status:       2,
+			Quantity:     decimal.NewFromFloat(4),
+			Price:        decimal.NewFromFloat(2500),
+			OwnerOrderId: uuid.New(),
+		},
+		{
+			Id:           uuid.New(),
+			TypeOrder:    1,
+			Status:       3,
+			Quantity:     decimal.NewFromFloat(3),
+			Price:        decimal.NewFromFloat(1520),
+			OwnerOrderId: uuid.New(),
+		},
+		{
+			Id:           uuid.New(),
+			TypeOrder:    2,
+			Status:       4,
+			Quantity:     decimal.NewFromFloat(6),
+			Price:        decimal.NewFromFloat(652),
+			OwnerOrderId: uuid.New(),
+		},
+	}
+
+	t.Run("Must return an array with all registered orders", func(t *testing.T) {
+		mockRepo.On("ListOrders").Return(orders, nil)
+
+		res, err := svc.ListOrders()
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res)
+		assert.Len(t, res, 5)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUpdateStatusOrder(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockEngine := new(MockEngine)
+	svc := service.NewService(mockRepo, mockEngine)
+
+	order := models.Orders{
+		Id:           uuid.MustParse("b794a8dc-415e-435c-8a44-551cf8244e68"),
+		TypeOrder:    1,
+		Status:       1,
+		Quantity:     decimal.NewFromFloat(100),
+		Price:        decimal.NewFromFloat(500),
+		OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+	}
+
+	t.Run("Should fail if the order status is less than 1", func(t *testing.T) {
+		res, err := svc.UpdateStatusOrder(-5, order.Id.String())
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "INVALID_INPUT: invalid status", err.Error())
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if the order status is greater than 4", func(t *testing.T) {
+		res, err := svc.UpdateStatusOrder(7, order.Id.String())
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "INVALID_INPUT: invalid status", err.Error())
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if the order cannot be found", func(t *testing.T) {
+		order.Id = uuid.MustParse("a7402f4d-e180-4963-bcc7-e02371a39dca")
+
+		mockRepo.On("GetOrderById", "a7402f4d-e180-4963-bcc7-e02371a39dca").Return(models.Orders{}, errors.New("record not found"))
+		res, err := svc.UpdateStatusOrder(2, order.Id.String())
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "NOT_FOUND: record not found", err.Error())
+	})
+
+	t.Run("Should fail if the order is already done", func(t *testing.T) {
+		order.Status = 3
+
+		mockRepo.On("GetOrderById", "b794a8dc-415e-435c-8a44-551cf8244e68").Return(order, nil)
+		res, err := svc.UpdateStatusOrder(2, "b794a8dc-415e-435c-8a44-551cf8244e68")
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "INVALID_INPUT: invalid update, this order was done", err.Error())
+	})
+
+	t.Run("Should fail if the order is already canceled", func(t *testing.T) {
+		orderT := models.Orders{
+			Id:           uuid.MustParse("096338a2-8bc6-4d4c-a8e0-d395981b7031"),
+			TypeOrder:    1,
+			Status:       4,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		mockRepo.On("GetOrderById", "096338a2-8bc6-4d4c-a8e0-d395981b7031").Return(orderT, nil)
+		res, err := svc.UpdateStatusOrder(2, "096338a2-8bc6-4d4c-a8e0-d395981b7031")
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "INVALID_INPUT: invalid update, this order was cancel", err.Error())
+	})
+
+	t.Run("Should return 'status in effect for this order' if the status sent is the same as the one already on the order", func(t *testing.T) {
+		orderT := models.Orders{
+			Id:           uuid.MustParse("f5ca0998-a0c1-4e6a-bdc9-f70521f9154f"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		mockRepo.On("GetOrderById", "f5ca0998-a0c1-4e6a-bdc9-f70521f9154f").Return(orderT, nil)
+		res, err := svc.UpdateStatusOrder(1, "f5ca0998-a0c1-4e6a-bdc9-f70521f9154f")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res)
+		assert.Equal(t, "status in effect for this order", res)
+	})
+
+	t.Run("Should fail if an order is waiting and an attempt is made to change the status to something other than OPEN or CANCEL", func(t *testing.T) {
+		orderT := models.Orders{
+			Id:           uuid.MustParse("f9c1554a-3fde-4619-8e0e-c0b56a752ede"),
+			TypeOrder:    1,
+			Status:       2,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		mockRepo.On("GetOrderById", "f9c1554a-3fde-4619-8e0e-c0b56a752ede").Return(orderT, nil)
+		res, err := svc.UpdateStatusOrder(3, "f9c1554a-3fde-4619-8e0e-c0b56a752ede")
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "INVALID_INPUT: invalid update, An order waiting only change status to OPEN or CANCEL", err.Error())
+	})
+
+	t.Run("Should surface a conflict with a concurrent change as a retryable error", func(t *testing.T) {
+		orderT := models.Orders{
+			Id:           uuid.MustParse("5d0e8a47-2b6f-4c1d-9e3a-7f8b6c5d4e21"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		mockRepo.On("GetOrderById", orderT.Id.String()).Return(orderT, nil)
+		mockEngine.On("UpdateStatus", orderT.Id.String(), models.CANCEL, models.CloseReasonRequested).Return(models.Orders{}, models.ErrorOrderConflict)
+		res, err := svc.UpdateStatusOrder(models.CANCEL, orderT.Id.String())
+
+		var appErr models.Error
+		assert.ErrorAs(t, err, &appErr)
+		assert.True(t, appErr.Retryable())
+		assert.Equal(t, models.StatusCodeConflict, appErr.StatusCode)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Should update successfully", func(t *testing.T) {
+		orderT := models.Orders{
+			Id:           uuid.MustParse("abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28"),
+			TypeOrder:    1,
+			Status:       1,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		orderF := models.Orders{
+			Id:           uuid.MustParse("abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28"),
+			TypeOrder:    1,
+			Status:       3,
+			Quantity:     decimal.NewFromFloat(100),
+			Price:        decimal.NewFromFloat(500),
+			OwnerOrderId: uuid.MustParse("0ee49ba6-30e6-4b8e-bfec-5bda90aa48ca"),
+		}
+
+		mockRepo.On("GetOrderById", "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28").Return(orderT, nil)
+		mockEngine.On("UpdateStatus", "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28", 3, models.CloseReasonRequested).Return(orderF, nil)
+		res, err := svc.UpdateStatusOrder(3, "abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res)
+		expect := fmt.Sprintf("order %s updated", orderF.Id)
+		assert.Equal(t, expect, "order abe7dffa-9ecc-4d40-b7d3-a5e2aca31f28 updated")
+	})
+}
+
+func TestAmendOrder(t *testing.T) {
+	client := models.Client{
+		Id:       uuid.MustParse("e4c1a9d2-7b3f-4f0e-8a6c-2d5b9e1f7c30"),
+		Balances: balances(decimal.NewFromInt(1000), decimal.NewFromInt(1)),
+	}
+	order := models.Orders{
+		Id:             uuid.MustParse("1f2e3d4c-5b6a-4798-8a7b-6c5d4e3f2a10"),
+		OwnerOrderId:   client.Id,
+		Market:         models.DefaultMarket,
+		TypeOrder:      models.BUY,
+		OrderKind:      models.LIMIT,
+		TimeInForce:    models.GTC,
+		Status:         models.PARTIALLY_FILLED,
+		Price:          decimal.NewFromInt(100),
+		Quantity:       decimal.NewFromInt(10),
+		FilledQuantity: decimal.NewFromInt(4),
+		HeldAmount:     decimal.NewFromInt(600),
+	}
+
+	t.Run("Should fail if neither price nor quantity is informed", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{})
+
+		assert.ErrorIs(t, err, models.ErrorEmptyAmendment)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Should fail if the new quantity is not greater than the quantity already filled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		quantity := decimal.NewFromInt(4)
+		mockRepo.On("GetOrderById", order.Id.String()).Return(order, nil)
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{Quantity: &quantity})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAmendQuantity)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Should fail if the new price is not a multiple of the market tick size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		price := decimal.RequireFromString("250.10")
+		market := btBRL
+		market.TickSize = decimal.RequireFromString("0.50")
+		mockRepo.On("GetOrderById", order.Id.String()).Return(order, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(market, nil)
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{Price: &price})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidPriceTick)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the new quantity of an iceberg order is not greater than its display quantity", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		iceberg := order
+		iceberg.DisplayQuantity = decimal.NewFromInt(5)
+		quantity := decimal.NewFromInt(5)
+		mockRepo.On("GetOrderById", iceberg.Id.String()).Return(iceberg, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+
+		res, err := svc.AmendOrder(iceberg.Id.String(), models.OrderAmendDtoInput{Quantity: &quantity})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidDisplayQuantity)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the display quantity of an amended iceberg order is not a multiple of the market lot size", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		iceberg := order
+		iceberg.DisplayQuantity = decimal.RequireFromString("2.5")
+		quantity := decimal.NewFromInt(8)
+		market := btBRL
+		market.LotSize = decimal.NewFromInt(1)
+		mockRepo.On("GetOrderById", iceberg.Id.String()).Return(iceberg, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(market, nil)
+
+		res, err := svc.AmendOrder(iceberg.Id.String(), models.OrderAmendDtoInput{Quantity: &quantity})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidDisplayQuantityLot)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the new notional is above the limit of the client's score band", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		// 10 * 2500 = 25000, above the 20000 of the lowest band.
+		price := decimal.NewFromInt(2500)
+		mockRepo.On("GetOrderById", order.Id.String()).Return(order, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{Price: &price})
+
+		assert.ErrorIs(t, err, models.ErrorOrderNotionalLimit)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the client cannot hold the new remainder", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		// 6 BT remaining at 300 needs 1800 held, 1200 more than the 600 already held.
+		price := decimal.NewFromInt(300)
+		mockRepo.On("GetOrderById", order.Id.String()).Return(order, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{Price: &price})
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Must only need what goes beyond the hold of the other order of an OCO pair", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		// The pair holds 1500, so the 1800 of the new remainder needs only 300 more.
+		linked := models.Orders{Id: uuid.New(), Status: models.OPEN, HeldAmount: decimal.NewFromInt(1500)}
+		oco := order
+		oco.LinkedOrderId = &linked.Id
+		price := decimal.NewFromInt(300)
+		mockRepo.On("GetOrderById", oco.Id.String()).Return(oco, nil)
+		mockRepo.On("GetOrderById", linked.Id.String()).Return(linked, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockEngine.On("Amend", oco.Id.String(), price, oco.Quantity).Return(oco, nil).Once()
+
+		res, err := svc.AmendOrder(oco.Id.String(), models.OrderAmendDtoInput{Price: &price})
+
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("order %s amended", oco.Id), res)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Must amend the order through the engine", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		price := decimal.NewFromInt(250)
+		mockRepo.On("GetOrderById", order.Id.String()).Return(order, nil)
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockEngine.On("Amend", order.Id.String(), price, order.Quantity).Return(order, nil).Once()
+
+		res, err := svc.AmendOrder(order.Id.String(), models.OrderAmendDtoInput{Price: &price})
+
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("order %s amended", order.Id), res)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestCancelClientOrders(t *testing.T) {
+	client := models.Client{Id: uuid.MustParse("8b3c6e1a-9d2f-4a7b-b5c4-3e2d1f0a9b87")}
+
+	t.Run("Should fail if the client cannot be found", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		mockRepo.On("GetClientById", client.Id.String()).Return(models.Client{}, models.ErrorNotFound)
+
+		res, err := svc.CancelClientOrders(client.Id.String(), models.OrderCancelFilter{})
+
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+		assert.Empty(t, res)
+		mockEngine.AssertNotCalled(t, "CancelClientOrders", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the minimum price is greater than the maximum price", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		minPrice, maxPrice := decimal.NewFromInt(200), decimal.NewFromInt(100)
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+
+		res, err := svc.CancelClientOrders(client.Id.String(), models.OrderCancelFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidPriceRange)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Must return the ids of the cancelled orders", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		filter := models.OrderCancelFilter{TypeOrder: models.SELL}
+		cancelled := []models.Orders{{Id: uuid.New()}, {Id: uuid.New()}}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		mockEngine.On("CancelClientOrders", client.Id.String(), filter, models.CloseReasonCancelAll).Return(cancelled, nil).Once()
+
+		res, err := svc.CancelClientOrders(client.Id.String(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{cancelled[0].Id, cancelled[1].Id}, res)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestExpireOrders(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiredAt := now.Add(-time.Second)
+
+	gtd := models.Orders{
+		Id:          uuid.MustParse("c2a4f7e1-5b3d-4e8a-9f0c-1d2e3f4a5b6c"),
+		TypeOrder:   models.BUY,
+		TimeInForce: models.GTD,
+		ExpiresAt:   &expiredAt,
+		Status:      models.OPEN,
+		Quantity:    decimal.NewFromInt(1),
+		Price:       decimal.NewFromInt(100),
+	}
+	stale := models.Orders{
+		Id:        uuid.MustParse("d3b5a8f2-6c4e-4f9b-a01d-2e3f4a5b6c7d"),
+		TypeOrder: models.SELL,
+		Status:    models.WAITING,
+		Quantity:  decimal.NewFromInt(1),
+		Price:     decimal.NewFromInt(100),
+		CreatedAt: now.Add(-48 * time.Hour),
+	}
+
+	t.Run("Must cancel expired GTD orders and stale orders recording why", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		staleBefore := now.Add(-24 * time.Hour)
+		mockRepo.On("ListOrdersToExpire", now, &staleBefore).Return([]models.Orders{gtd, stale}, nil).Once()
+		mockEngine.On("UpdateStatus", gtd.Id.String(), models.CANCEL, models.CloseReasonExpired).Return(models.Orders{}, nil).Once()
+		mockEngine.On("UpdateStatus", stale.Id.String(), models.CANCEL, models.CloseReasonStale).Return(models.Orders{}, nil).Once()
+
+		expired, err := svc.ExpireOrders(now, 24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, expired)
+		mockRepo.AssertExpectations(t)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Must only look for GTD orders when there is no maximum age", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		mockRepo.On("ListOrdersToExpire", now, (*time.Time)(nil)).Return([]models.Orders{gtd}, nil).Once()
+		mockEngine.On("UpdateStatus", gtd.Id.String(), models.CANCEL, models.CloseReasonExpired).Return(models.Orders{}, nil).Once()
+
+		expired, err := svc.ExpireOrders(now, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		mockRepo.AssertExpectations(t)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Must skip orders that already left the book", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		done := gtd
+		done.Status = models.DONE
+		mockRepo.On("ListOrdersToExpire", now, (*time.Time)(nil)).Return([]models.Orders{done}, nil).Once()
+
+		expired, err := svc.ExpireOrders(now, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, expired)
+		mockEngine.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Must keep expiring the other orders when one cannot be cancelled", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		staleBefore := now.Add(-24 * time.Hour)
+		mockRepo.On("ListOrdersToExpire", now, &staleBefore).Return([]models.Orders{gtd, stale}, nil).Once()
+		mockEngine.On("UpdateStatus", gtd.Id.String(), models.CANCEL, models.CloseReasonExpired).Return(models.Orders{}, models.ErrorInvalidUpdateOrderDone).Once()
+		mockEngine.On("UpdateStatus", stale.Id.String(), models.CANCEL, models.CloseReasonStale).Return(models.Orders{}, nil).Once()
+
+		expired, err := svc.ExpireOrders(now, 24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		mockEngine.AssertExpectations(t)
+	})
+}
+
+func TestGetClientById(t *testing.T) {
+	mockRepo := new(MockRepo)
+	svc := service.NewService(mockRepo, new(MockEngine))
+
+	t.Run("Should fail if the client cannot be found", func(t *testing.T) {
+		client := models.Client{
+			Id:       uuid.MustParse("bc7e77eb-12d1-4e3a-b4af-8682302dc0b4"),
+			Balances: balances(decimal.NewFromFloat(12455), decimal.NewFromFloat(14)),
+			Score:    91,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(models.Client{}, errors.New("record not found"))
+		res, err := svc.GetClientById(client.Id.String())
+
+		assert.Error(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, "record not found", err.Error())
+	})
+
+	t.Run("Must return the client successfully", func(t *testing.T) {
+		client := models.Client{
+			Id:       uuid.MustParse("0b20b052-abd2-4da8-ac7e-5632118be457"),
+			Balances: balances(decimal.NewFromFloat(12455), decimal.NewFromFloat(14)),
+			Score:    91,
+		}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil)
+		res, err := svc.GetClientById(client.Id.String())
+
+		expect := models.ClientDtoOutput{
+			Id:         client.Id,
+			BalanceBRL: client.Available(models.AssetBRL),
+			BalanceBT:  client.Available(models.AssetBT),
+			Balances:   client.Balances,
+			Score:      client.Score,
+		}
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res)
+		assert.Equal(t, expect, res)
+	})
+}
+
+func TestCreateClient(t *testing.T) {
+	t.Run("Should fail if a balance is negative", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		res, err := svc.CreateClient(models.ClientDtoInput{BalanceBRL: decimal.NewFromInt(-1)})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidBalance)
+		assert.Empty(t, res)
+		mockRepo.AssertNotCalled(t, "CreateClient", mock.Anything)
+	})
+
+	t.Run("Should fail if the BT balance has more decimal places than satoshis", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.CreateClient(models.ClientDtoInput{BalanceBT: decimal.RequireFromString("0.000000001")})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidBalanceBTPrecision)
+	})
+
+	t.Run("Should fail if the score is greater than 100", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.CreateClient(models.ClientDtoInput{Score: 101})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidScore)
+	})
+
+	t.Run("Must register the client with a new id", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		input := models.ClientDtoInput{
+			BalanceBRL: decimal.NewFromInt(1500),
+			BalanceBT:  decimal.RequireFromString("0.5"),
+			Score:      80,
+		}
+		mockRepo.On("CreateClient", mock.MatchedBy(func(c models.Client) bool {
+			return c.Id != uuid.Nil && c.Available(models.AssetBRL).Equal(input.BalanceBRL) && c.Available(models.AssetBT).Equal(input.BalanceBT) && c.Score == 80
+		})).Return(models.Client{Id: uuid.New(), Balances: balances(input.BalanceBRL, input.BalanceBT), Score: 80}, nil).Once()
+
+		res, err := svc.CreateClient(input)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, res.Id)
+		assert.Equal(t, 80, res.Score)
+		assert.True(t, res.BalanceBT.Equal(input.BalanceBT))
+		assert.Len(t, res.Balances, 2)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestListClients(t *testing.T) {
+	t.Run("Should fail if the page size is greater than the maximum", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.ListClients(models.Pagination{Page: 1, PageSize: models.MaxPageSize + 1})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidPagination)
+	})
+
+	t.Run("Must return the first page with the default size when none is informed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		client := models.Client{Id: uuid.New(), Balances: balances(decimal.NewFromInt(10), decimal.Zero), Score: 50}
+		mockRepo.On("ListClients", models.Pagination{Page: 1, PageSize: models.DefaultPageSize}).
+			Return([]models.Client{client}, int64(21), nil).Once()
+
+		res, err := svc.ListClients(models.Pagination{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, res.Page)
+		assert.Equal(t, models.DefaultPageSize, res.PageSize)
+		assert.Equal(t, int64(21), res.Total)
+		assert.Equal(t, []models.ClientDtoOutput{models.NewClientDtoOutput(client)}, res.Clients)
+	})
+}
+
+func TestUpdateClient(t *testing.T) {
+	id := "0b20b052-abd2-4da8-ac7e-5632118be457"
+
+	t.Run("Should fail if no field is informed", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.UpdateClient(id, models.ClientUpdateDtoInput{})
+
+		assert.ErrorIs(t, err, models.ErrorEmptyClientUpdate)
+	})
+
+	t.Run("Should fail if the score is less than 0", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+		score := -1
+
+		_, err := svc.UpdateClient(id, models.ClientUpdateDtoInput{Score: &score})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidScore)
+	})
+
+	t.Run("Should fail if the client cannot be found", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		score := 40
+
+		mockRepo.On("UpdateClientScore", id, 40).Return(models.Client{}, models.ErrorNotFound)
+
+		_, err := svc.UpdateClient(id, models.ClientUpdateDtoInput{Score: &score})
+
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+	})
+
+	t.Run("Must update the score", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		score := 40
+
+		mockRepo.On("UpdateClientScore", id, 40).Return(models.Client{Id: uuid.MustParse(id), Score: 40}, nil)
+
+		res, err := svc.UpdateClient(id, models.ClientUpdateDtoInput{Score: &score})
 
-		expect := models.ClientDtoOutput{
-			Id:         client.Id,
-			BalanceBRL: client.BalanceBRL,
-			BalanceBT:  client.BalanceBT,
-			Score:      client.Score,
-		}
 		assert.NoError(t, err)
-		assert.NotEmpty(t, res)
-		assert.Equal(t, expect, res)
+		assert.Equal(t, 40, res.Score)
+	})
+}
+
+func TestDeposit(t *testing.T) {
+	clientId := "0b20b052-abd2-4da8-ac7e-5632118be457"
+
+	t.Run("Should fail if the asset is unknown", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		_, err := svc.Deposit(clientId, models.LedgerMovementDtoInput{Asset: "ETH", Amount: decimal.NewFromInt(1)})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAsset)
+	})
+
+	t.Run("Should fail if the amount is not positive", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		_, err := svc.Deposit(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAmount)
+	})
+
+	t.Run("Should fail if a BRL amount has more decimal places than centavos", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		_, err := svc.Deposit(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: decimal.RequireFromString("10.001")})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAmountPrecision)
+	})
+
+	t.Run("Must record the deposit in the ledger", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		amount := decimal.RequireFromString("0.00000001")
+		journal := models.NewJournal(models.JournalDeposit, nil)
+		journal.Transfer(models.AssetBT, amount, models.ExternalAccount(), models.AvailableAccount(uuid.MustParse(clientId)))
+		mockRepo.On("Deposit", clientId, models.AssetBT, amount).Return(journal, nil).Once()
+
+		res, err := svc.Deposit(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBT, Amount: amount})
+
+		assert.NoError(t, err)
+		assert.Equal(t, journal, res)
+		assert.True(t, res.Balanced())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must accept the assets of every listed market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		ethBRL := models.Market{Symbol: "ETH-BRL", BaseAsset: "ETH", QuoteAsset: models.AssetBRL}
+		amount := decimal.RequireFromString("1.5")
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL, ethBRL}, nil).Once()
+		mockRepo.On("Deposit", clientId, "ETH", amount).Return(models.Journal{}, nil).Once()
+
+		_, err := svc.Deposit(clientId, models.LedgerMovementDtoInput{Asset: "ETH", Amount: amount})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestRequestWithdrawal(t *testing.T) {
+	clientId := "0b20b052-abd2-4da8-ac7e-5632118be457"
+
+	t.Run("Should fail if the client does not have the amount available", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		amount := decimal.NewFromInt(500)
+		mockRepo.On("RequestWithdrawal", clientId, models.AssetBRL, amount).Return(models.Withdrawal{}, models.ErrorInsufficientBalance).Once()
+
+		_, err := svc.RequestWithdrawal(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: amount})
+
+		assert.ErrorIs(t, err, models.ErrorInsufficientBalance)
+	})
+
+	t.Run("Should not reach the ledger when the amount is negative", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		_, err := svc.RequestWithdrawal(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: decimal.NewFromInt(-5)})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAmount)
+		mockRepo.AssertNotCalled(t, "RequestWithdrawal", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should fail if the amount is finer than the precision of the asset", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		client := models.Client{Balances: balances(decimal.RequireFromString("100.100001"), decimal.Zero)}
+		mockRepo.On("GetClientById", clientId).Return(client, nil).Once()
+
+		_, err := svc.RequestWithdrawal(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: decimal.RequireFromString("50.000001")})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidAmountPrecision)
+		mockRepo.AssertNotCalled(t, "RequestWithdrawal", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Must withdraw the whole available balance even when it is finer than the precision of the asset", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		// A trade of 0.0001 ETH at 1000.01 BRL settles 0.100001 BRL.
+		amount := decimal.RequireFromString("100.100001")
+		client := models.Client{Balances: balances(amount, decimal.Zero)}
+		withdrawal := models.Withdrawal{Id: uuid.New(), Asset: models.AssetBRL, Amount: amount, Status: models.WithdrawalRequested}
+		mockRepo.On("GetClientById", clientId).Return(client, nil).Once()
+		mockRepo.On("RequestWithdrawal", clientId, models.AssetBRL, amount).Return(withdrawal, nil).Once()
+
+		res, err := svc.RequestWithdrawal(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: amount})
+
+		assert.NoError(t, err)
+		assert.Equal(t, withdrawal, res)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must open the withdrawal as REQUESTED", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		mockRepo.On("ListMarkets").Return([]models.Market{btBRL}, nil)
+
+		amount := decimal.NewFromInt(500)
+		withdrawal := models.Withdrawal{Id: uuid.New(), ClientId: uuid.MustParse(clientId), Asset: models.AssetBRL, Amount: amount, Status: models.WithdrawalRequested}
+		mockRepo.On("RequestWithdrawal", clientId, models.AssetBRL, amount).Return(withdrawal, nil).Once()
+
+		res, err := svc.RequestWithdrawal(clientId, models.LedgerMovementDtoInput{Asset: models.AssetBRL, Amount: amount})
+
+		assert.NoError(t, err)
+		assert.Equal(t, withdrawal, res)
+	})
+}
+
+func TestListWithdrawals(t *testing.T) {
+	t.Run("Should fail if the status is unknown", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.ListWithdrawals("PENDING", models.Pagination{})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidWithdrawalStatus)
+	})
+
+	t.Run("Must list the pending withdrawals when no status is informed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		withdrawals := []models.Withdrawal{{Id: uuid.New(), Status: models.WithdrawalRequested}}
+		mockRepo.On("ListWithdrawals", []string{models.WithdrawalRequested, models.WithdrawalApproved}, models.Pagination{Page: 1, PageSize: models.DefaultPageSize}).
+			Return(withdrawals, int64(1), nil).Once()
+
+		res, err := svc.ListWithdrawals("", models.Pagination{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, withdrawals, res.Withdrawals)
+		assert.Equal(t, int64(1), res.Total)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestApproveWithdrawal(t *testing.T) {
+	id := "5f0c2a8e-3b1d-4c6e-9a7f-8d2e1b0c4a63"
+	small := models.Withdrawal{Id: uuid.MustParse(id), Asset: models.AssetBT, Amount: decimal.RequireFromString("0.5"), Status: models.WithdrawalRequested}
+	large := models.Withdrawal{Id: uuid.MustParse(id), Asset: models.AssetBRL, Amount: decimal.NewFromInt(80000), Status: models.WithdrawalRequested}
+
+	t.Run("Should fail if no operator is informed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetWithdrawalById", id).Return(small, nil)
+
+		_, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidOperator)
+		mockRepo.AssertNotCalled(t, "UpdateWithdrawal", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Must approve a withdrawal up to the threshold with a single operator", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		approved := small
+		approved.Status = models.WithdrawalApproved
+		approved.ApprovedBy = "ana"
+		mockRepo.On("GetWithdrawalById", id).Return(small, nil)
+		mockRepo.On("UpdateWithdrawal", small, approved).Return(approved, nil).Once()
+
+		res, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WithdrawalApproved, res.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Must keep a withdrawal above the threshold REQUESTED after the first approval", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		firstApproval := large
+		firstApproval.ApprovedBy = "ana"
+		mockRepo.On("GetWithdrawalById", id).Return(large, nil)
+		mockRepo.On("UpdateWithdrawal", large, firstApproval).Return(firstApproval, nil).Once()
+
+		res, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WithdrawalRequested, res.Status)
+		assert.Equal(t, "ana", res.ApprovedBy)
+	})
+
+	t.Run("Should fail if the same operator approves twice", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		firstApproval := large
+		firstApproval.ApprovedBy = "ana"
+		mockRepo.On("GetWithdrawalById", id).Return(firstApproval, nil)
+
+		_, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.ErrorIs(t, err, models.ErrorSameOperatorApproval)
+	})
+
+	t.Run("Must approve a withdrawal above the threshold after a second operator", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		firstApproval := large
+		firstApproval.ApprovedBy = "ana"
+		approved := firstApproval
+		approved.SecondApprovedBy = "bruno"
+		approved.Status = models.WithdrawalApproved
+		mockRepo.On("GetWithdrawalById", id).Return(firstApproval, nil)
+		mockRepo.On("UpdateWithdrawal", firstApproval, approved).Return(approved, nil).Once()
+
+		res, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "bruno"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WithdrawalApproved, res.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should surface a concurrent review as a retryable error", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetWithdrawalById", id).Return(small, nil)
+		mockRepo.On("UpdateWithdrawal", small, mock.Anything).Return(models.Withdrawal{}, models.ErrorWithdrawalConflict)
+
+		_, err := svc.ApproveWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		var appErr models.Error
+		assert.ErrorAs(t, err, &appErr)
+		assert.True(t, appErr.Retryable())
+	})
+}
+
+func TestRejectWithdrawal(t *testing.T) {
+	id := "5f0c2a8e-3b1d-4c6e-9a7f-8d2e1b0c4a63"
+
+	t.Run("Should fail if the withdrawal was already sent", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetWithdrawalById", id).Return(models.Withdrawal{Status: models.WithdrawalSent}, nil)
+
+		_, err := svc.RejectWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidRejectWithdrawal)
+	})
+
+	t.Run("Must reject an approved withdrawal", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		withdrawal := models.Withdrawal{Id: uuid.MustParse(id), Status: models.WithdrawalApproved, ApprovedBy: "ana"}
+		rejected := withdrawal
+		rejected.Status = models.WithdrawalRejected
+		rejected.RejectedBy = "bruno"
+		mockRepo.On("GetWithdrawalById", id).Return(withdrawal, nil)
+		mockRepo.On("UpdateWithdrawal", withdrawal, rejected).Return(rejected, nil).Once()
+
+		res, err := svc.RejectWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "bruno"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WithdrawalRejected, res.Status)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSendWithdrawal(t *testing.T) {
+	id := "5f0c2a8e-3b1d-4c6e-9a7f-8d2e1b0c4a63"
+
+	t.Run("Should fail if the withdrawal was not approved", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetWithdrawalById", id).Return(models.Withdrawal{Status: models.WithdrawalRequested, ApprovedBy: "ana"}, nil)
+
+		_, err := svc.SendWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidSendWithdrawal)
+	})
+
+	t.Run("Must send an approved withdrawal", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		withdrawal := models.Withdrawal{Id: uuid.MustParse(id), Status: models.WithdrawalApproved, ApprovedBy: "ana"}
+		sent := withdrawal
+		sent.Status = models.WithdrawalSent
+		sent.SentBy = "ana"
+		mockRepo.On("GetWithdrawalById", id).Return(withdrawal, nil)
+		mockRepo.On("UpdateWithdrawal", withdrawal, sent).Return(sent, nil).Once()
+
+		res, err := svc.SendWithdrawal(id, models.WithdrawalActionDtoInput{OperatorId: "ana"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.WithdrawalSent, res.Status)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestListClientLedger(t *testing.T) {
+	clientId := "0b20b052-abd2-4da8-ac7e-5632118be457"
+
+	t.Run("Should fail if the client cannot be found", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetClientById", clientId).Return(models.Client{}, models.ErrorNotFound)
+
+		_, err := svc.ListClientLedger(clientId, models.Pagination{})
+
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+	})
+
+	t.Run("Must return the entries of the client in the requested page", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		entries := []models.LedgerEntry{{Id: uuid.New(), Account: models.AccountAvailable, Asset: models.AssetBRL, Amount: decimal.NewFromInt(10)}}
+		mockRepo.On("GetClientById", clientId).Return(models.Client{Id: uuid.MustParse(clientId)}, nil)
+		mockRepo.On("ListLedgerEntries", clientId, models.Pagination{Page: 2, PageSize: models.DefaultPageSize}).Return(entries, int64(21), nil)
+
+		res, err := svc.ListClientLedger(clientId, models.Pagination{Page: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entries, res.Entries)
+		assert.Equal(t, 2, res.Page)
+		assert.Equal(t, int64(21), res.Total)
+	})
+}
+
+func TestCreateMarket(t *testing.T) {
+	input := models.MarketDtoInput{
+		BaseAsset:   "ETH",
+		QuoteAsset:  models.AssetBRL,
+		TickSize:    decimal.RequireFromString("0.01"),
+		LotSize:     decimal.RequireFromString("0.0001"),
+		MinNotional: decimal.NewFromInt(10),
+	}
+
+	t.Run("Should fail if the assets are equal", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		invalid := input
+		invalid.QuoteAsset = "ETH"
+		_, err := svc.CreateMarket(invalid)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarketAsset)
+	})
+
+	t.Run("Should fail if an asset is not an uppercase code", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		invalid := input
+		invalid.BaseAsset = "eth-1"
+		_, err := svc.CreateMarket(invalid)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarketAsset)
+	})
+
+	t.Run("Should fail if the tick size is not positive", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		invalid := input
+		invalid.TickSize = decimal.Zero
+		_, err := svc.CreateMarket(invalid)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarketSizes)
+	})
+
+	t.Run("Should fail if the score bands have no notional limit for the quote asset", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		invalid := input
+		invalid.QuoteAsset = "DAI"
+		_, err := svc.CreateMarket(invalid)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarketQuoteAsset)
+		mockRepo.AssertNotCalled(t, "CreateMarket", mock.Anything)
+	})
+
+	t.Run("Must list a market quoted in an asset with configured notional limits", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+		svc.Policy = models.DefaultScorePolicy.WithNotionalLimits("DAI", []decimal.Decimal{
+			decimal.NewFromInt(200000), decimal.NewFromInt(50000), decimal.NewFromInt(20000), decimal.NewFromInt(4000),
+		})
+
+		inDAI := input
+		inDAI.QuoteAsset = "DAI"
+		mockRepo.On("GetMarket", "ETH-DAI").Return(models.Market{}, models.ErrorNotFound).Once()
+		mockRepo.On("CreateMarket", mock.Anything).Return(models.Market{Symbol: "ETH-DAI"}, nil).Once()
+
+		res, err := svc.CreateMarket(inDAI)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ETH-DAI", res.Symbol)
+		mockRepo.AssertExpectations(t)
+		assert.False(t, models.DefaultScorePolicy.Covers("DAI"))
+	})
+
+	t.Run("Must list the BTC-USDT market", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		btcUSDT := models.MarketDtoInput{
+			BaseAsset:  "BTC",
+			QuoteAsset: models.AssetUSDT,
+			TickSize:   decimal.RequireFromString("0.01"),
+			LotSize:    decimal.RequireFromString("0.00001"),
+		}
+		mockRepo.On("GetMarket", "BTC-USDT").Return(models.Market{}, models.ErrorNotFound).Once()
+		mockRepo.On("CreateMarket", mock.MatchedBy(func(m models.Market) bool {
+			return m.Symbol == "BTC-USDT" && m.BaseAsset == "BTC" && m.QuoteAsset == models.AssetUSDT
+		})).Return(models.Market{Symbol: "BTC-USDT"}, nil).Once()
+
+		res, err := svc.CreateMarket(btcUSDT)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "BTC-USDT", res.Symbol)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if the market is already listed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetMarket", "ETH-BRL").Return(models.Market{Symbol: "ETH-BRL"}, nil).Once()
+
+		_, err := svc.CreateMarket(input)
+
+		assert.ErrorIs(t, err, models.ErrorMarketAlreadyListed)
+		mockRepo.AssertNotCalled(t, "CreateMarket", mock.Anything)
+	})
+
+	t.Run("Must list the market under the symbol of its assets", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := service.NewService(mockRepo, new(MockEngine))
+
+		mockRepo.On("GetMarket", "ETH-BRL").Return(models.Market{}, models.ErrorNotFound).Once()
+		mockRepo.On("CreateMarket", mock.MatchedBy(func(m models.Market) bool {
+			return m.Symbol == "ETH-BRL" && m.BaseAsset == "ETH" && m.QuoteAsset == models.AssetBRL && m.LotSize.Equal(input.LotSize)
+		})).Return(models.Market{Symbol: "ETH-BRL"}, nil).Once()
+
+		res, err := svc.CreateMarket(input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ETH-BRL", res.Symbol)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetBook(t *testing.T) {
+	t.Run("Must return bids and asks aggregated by price level", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		bids := []models.BookLevel{
+			{Price: decimal.NewFromInt(101), Quantity: decimal.RequireFromString("1.5"), Orders: 2},
+			{Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(3), Orders: 1},
+		}
+		asks := []models.BookLevel{
+			{Price: decimal.NewFromInt(102), Quantity: decimal.RequireFromString("0.25"), Orders: 1},
+		}
+		mockRepo.On("GetMarket", models.DefaultMarket).Return(btBRL, nil).Once()
+		mockEngine.On("Book", models.DefaultMarket, 5).Return(models.BookDtoOutput{Bids: bids, Asks: asks}, nil).Once()
+
+		res, err := svc.GetBook("", 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, bids, res.Bids)
+		assert.Equal(t, asks, res.Asks)
+		mockEngine.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if the depth is out of range", func(t *testing.T) {
+		svc := service.NewService(new(MockRepo), new(MockEngine))
+
+		_, err := svc.GetBook(models.DefaultMarket, 0)
+		assert.ErrorIs(t, err, models.ErrorInvalidBookDepth)
+
+		_, err = svc.GetBook(models.DefaultMarket, models.MaxBookDepth+1)
+		assert.ErrorIs(t, err, models.ErrorInvalidBookDepth)
+	})
+
+	t.Run("Should fail if the market is not listed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockEngine := new(MockEngine)
+		svc := service.NewService(mockRepo, mockEngine)
+
+		mockRepo.On("GetMarket", "ETH-BRL").Return(models.Market{}, models.ErrorNotFound).Once()
+
+		_, err := svc.GetBook("ETH-BRL", 5)
+
+		assert.ErrorIs(t, err, models.ErrorInvalidMarket)
+		mockEngine.AssertNotCalled(t, "Book", mock.Anything, mock.Anything)
+	})
+}
+
+func TestListTrades(t *testing.T) {
+	mockRepo := new(MockRepo)
+	svc := service.NewService(mockRepo, new(MockEngine))
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Must return the trades with the taker side translated", func(t *testing.T) {
+		trade := models.Trade{
+			Id:          uuid.New(),
+			BuyOrderId:  uuid.New(),
+			SellOrderId: uuid.New(),
+			BuyerId:     uuid.New(),
+			SellerId:    uuid.New(),
+			TakerSide:   models.SELL,
+			Price:       decimal.NewFromInt(350000),
+			Quantity:    decimal.RequireFromString("0.01"),
+			Notional:    decimal.NewFromInt(3500),
+			CreatedAt:   from.Add(time.Hour),
+		}
+		trade.MakerOrderId, trade.TakerOrderId = trade.BuyOrderId, trade.SellOrderId
+		trade.BuyerFee = decimal.RequireFromString("0.00001")
+		trade.SellerFee = decimal.NewFromInt(7)
+
+		filter := models.TradeFilter{From: &from, To: &to}
+		mockRepo.On("ListTrades", filter).Return([]models.Trade{trade}, nil).Once()
+
+		res, err := svc.ListTrades(filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "SELL", res[0].TakerSide)
+		assert.Equal(t, trade.SellOrderId, res[0].TakerOrderId)
+		assert.True(t, trade.BuyerFee.Equal(res[0].BuyerFee))
+		assert.True(t, trade.SellerFee.Equal(res[0].SellerFee))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Should fail if from is after to", func(t *testing.T) {
+		res, err := svc.ListTrades(models.TradeFilter{From: &to, To: &from})
+
+		assert.ErrorIs(t, err, models.ErrorInvalidTimeRange)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Should fail to list the trades of a client that does not exist", func(t *testing.T) {
+		clientId := "3f8d5b83-4e58-4bd1-a6b1-5a2f52e1f3c4"
+		mockRepo.On("GetClientById", clientId).Return(models.Client{}, models.ErrorNotFound).Once()
+
+		res, err := svc.ListClientTrades(clientId, models.TradeFilter{})
+
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+		assert.Empty(t, res)
+	})
+
+	t.Run("Must list only the trades of the client", func(t *testing.T) {
+		client := models.Client{Id: uuid.MustParse("7c1f0b55-3f0c-4a0b-9c1e-0c3bde2f4a11")}
+		mockRepo.On("GetClientById", client.Id.String()).Return(client, nil).Once()
+		mockRepo.On("ListTrades", models.TradeFilter{ClientId: client.Id.String()}).Return([]models.Trade{}, nil).Once()
+
+		res, err := svc.ListClientTrades(client.Id.String(), models.TradeFilter{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+		mockRepo.AssertExpectations(t)
 	})
 }
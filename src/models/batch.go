@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchOrders is the largest number of orders accepted by one batch.
+const MaxBatchOrders = 100
+
+// OrderBatchDtoInput is a batch of orders. With AllOrNothing either every order is
+// accepted or none is created.
+type OrderBatchDtoInput struct {
+	Orders       []Orders `json:"orders"`
+	AllOrNothing bool     `json:"all_or_nothing"`
+}
+
+// OrderBatchResult is the outcome of one order of a batch, in the position it was sent.
+type OrderBatchResult struct {
+	Index  int        `json:"index"`
+	Id     *uuid.UUID `json:"id,omitempty"`
+	Error  string     `json:"error,omitempty"`
+	Kind   ErrorKind  `json:"kind,omitempty"`
+	Status int        `json:"status"`
+}
+
+// NewOrderBatchResult builds the result of an accepted order, or of a rejected one
+// when err is not nil.
+func NewOrderBatchResult(index int, id uuid.UUID, err error) OrderBatchResult {
+	if err == nil {
+		return OrderBatchResult{Index: index, Id: &id, Status: StatusCodeCreated}
+	}
+
+	var appErr Error
+	if errors.As(err, &appErr) {
+		return OrderBatchResult{Index: index, Error: appErr.Message, Kind: appErr.Kind, Status: appErr.StatusCode}
+	}
+
+	return OrderBatchResult{Index: index, Error: err.Error(), Kind: ErrorKindInternal, Status: StatusCodeInternal}
+}
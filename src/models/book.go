@@ -0,0 +1,22 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// BookLevel is one price level of the book: the remaining quantity of every
+// resting order at that price and how many orders make it.
+type BookLevel struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Orders   int             `json:"orders"`
+}
+
+type BookDtoOutput struct {
+	Market string      `json:"market"`
+	Bids   []BookLevel `json:"bids"`
+	Asks   []BookLevel `json:"asks"`
+}
+
+const (
+	DefaultBookDepth = 10
+	MaxBookDepth     = 100
+)
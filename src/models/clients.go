@@ -5,44 +5,424 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type ClientDtoOutput struct {
-	Id         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
-	BalanceBRL float64   `json:"balance_brl"`
-	BalanceBT  float64   `json:"balance_bt"`
-	Score      int       `json:"score,omitempty"`
+	Id         uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
+	BalanceBRL decimal.Decimal `json:"balance_brl"`
+	BalanceBT  decimal.Decimal `json:"balance_bt"`
+	HeldBRL    decimal.Decimal `json:"held_brl"`
+	HeldBT     decimal.Decimal `json:"held_bt"`
+	Balances   []Balance       `json:"balances"` // Saldos de todos os ativos, inclusive BRL e BT
+	Score      int             `json:"score,omitempty"`
+}
+
+// NewClientDtoOutput builds the response of a client, without its orders.
+func NewClientDtoOutput(client Client) ClientDtoOutput {
+	balances := client.Balances
+	if balances == nil {
+		balances = []Balance{}
+	}
+
+	return ClientDtoOutput{
+		Id:         client.Id,
+		BalanceBRL: client.Available(AssetBRL),
+		BalanceBT:  client.Available(AssetBT),
+		HeldBRL:    client.Held(AssetBRL),
+		HeldBT:     client.Held(AssetBT),
+		Balances:   balances,
+		Score:      client.Score,
+	}
+}
+
+// ClientDtoInput registers a new client with its opening balances.
+type ClientDtoInput struct {
+	BalanceBRL decimal.Decimal `json:"balance_brl"`
+	BalanceBT  decimal.Decimal `json:"balance_bt"`
+	Score      int             `json:"score"`
+}
+
+// ClientUpdateDtoInput carries the fields of a client that can be changed.
+type ClientUpdateDtoInput struct {
+	Score *int `json:"score"`
+}
+
+// ClientPageDtoOutput is one page of the registered clients.
+type ClientPageDtoOutput struct {
+	Clients  []ClientDtoOutput `json:"clients"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Total    int64             `json:"total"`
+}
+
+const (
+	MinScore = 0
+	MaxScore = 100
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Pagination selects one page of a listing. Pages start at 1.
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// Offset returns how many records come before the page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
 }
 
 type OrderDtoOutput struct {
-	Id            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
-	OwnerOrderId  uuid.UUID `gorm:"type:uuid;not null" json:"owner_order_id"` // Chave estrangeira
-	PriceOrderBRL float64   `json:"price_order_brl"`
-	PriceOrderBT  float64   `json:"price_order_bt"`
-	TypeOrder     string    `json:"type_order"`
-	Status        string    `json:"status,omitempty"`
+	Id                uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
+	OwnerOrderId      uuid.UUID        `gorm:"type:uuid;not null" json:"owner_order_id"` // Chave estrangeira
+	Market            string           `json:"market"`
+	Price             decimal.Decimal  `json:"price"`
+	StopPrice         *decimal.Decimal `json:"stop_price,omitempty"`
+	Quantity          decimal.Decimal  `json:"quantity"`
+	DisplayQuantity   *decimal.Decimal `json:"display_quantity,omitempty"`
+	FilledQuantity    decimal.Decimal  `json:"filled_quantity"`
+	RemainingQuantity decimal.Decimal  `json:"remaining_quantity"`
+	Notional          decimal.Decimal  `json:"notional"`
+	HeldAmount        decimal.Decimal  `json:"held_amount"`
+	TypeOrder         string           `json:"type_order"`
+	OrderKind         string           `json:"order_kind"`
+	TimeInForce       string           `json:"time_in_force"`
+	ExpiresAt         *time.Time       `json:"expires_at,omitempty"`
+	Status            string           `json:"status,omitempty"`
+	CloseReason       string           `json:"close_reason,omitempty"`
+	LinkedOrderId     *uuid.UUID       `json:"linked_order_id,omitempty"`
+}
+
+// OrderAmendDtoInput carries the new price and/or quantity of a resting order.
+type OrderAmendDtoInput struct {
+	Price    *decimal.Decimal `json:"price"`
+	Quantity *decimal.Decimal `json:"quantity"`
+}
+
+// OrderCancelFilter narrows which orders of a client are cancelled at once. Zero
+// values mean no filter; the price range is inclusive.
+type OrderCancelFilter struct {
+	Market    string
+	TypeOrder int
+	MinPrice  *decimal.Decimal
+	MaxPrice  *decimal.Decimal
 }
 
 type Client struct {
-	Id         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
-	BalanceBRL float64   `json:"balance_brl"`
-	BalanceBT  float64   `json:"balance_bt"`
-	Score      int       `json:"score,omitempty"`
-	CreatedAt  time.Time `json:"created_at" gorm:"default:now()`
+	Id        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
+	Score     int       `json:"score,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:now()"`
+
+	Balances []Balance `gorm:"foreignKey:ClientId" json:"balances,omitempty"`
+	Orders   []Orders  `gorm:"foreignKey:OwnerOrderId" json:"orders,omitempty"`
+}
+
+// Balance is what a client has of one asset. A client without a balance of an
+// asset has none of it.
+type Balance struct {
+	ClientId  uuid.UUID       `gorm:"type:uuid;primaryKey" json:"-"`
+	Asset     string          `gorm:"primaryKey" json:"asset"`
+	Available decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"available"` // Saldo disponível
+	Held      decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"held"`      // Saldo reservado por propostas e saques
+}
+
+// Available returns the available balance of the asset.
+func (c Client) Available(asset string) decimal.Decimal {
+	return c.Balance(AccountAvailable, asset)
+}
 
-	Orders []Orders `gorm:"foreignKey:OwnerOrderId" json:"orders,omitempty"`
+// Held returns the balance of the asset held by orders and withdrawals.
+func (c Client) Held(asset string) decimal.Decimal {
+	return c.Balance(AccountHeld, asset)
 }
 
 type Orders struct {
-	Id            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
-	OwnerOrderId  uuid.UUID `gorm:"type:uuid;not null" json:"owner_order_id"` // Chave estrangeira
-	PriceOrderBRL float64   `json:"price_order_brl"`
-	PriceOrderBT  float64   `json:"price_order_bt"`
-	TypeOrder     int       `json:"type_order"`
-	Status        int       `json:"status,omitempty"`
-	CreatedAt     time.Time `json:"created_at" gorm:"default:now()"`
+	Id              uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
+	OwnerOrderId    uuid.UUID       `gorm:"type:uuid;not null" json:"owner_order_id"`                       // Chave estrangeira
+	Market          string          `json:"market" gorm:"not null;default:'BT-BRL';index"`                  // Símbolo do mercado, BT-BRL quando não informado
+	Price           decimal.Decimal `json:"price" gorm:"type:numeric(36,18)"`                               // Preço limite no ativo de cotação por unidade do ativo base
+	StopPrice       decimal.Decimal `json:"stop_price" gorm:"type:numeric(36,18);not null;default:0"`       // Preço de disparo das ordens stop, 0 nas demais
+	Quantity        decimal.Decimal `json:"quantity" gorm:"type:numeric(36,18)"`                            // Quantidade do ativo base
+	DisplayQuantity decimal.Decimal `json:"display_quantity" gorm:"type:numeric(36,18);not null;default:0"` // Parte exibida no livro das ordens iceberg, 0 nas demais
+	FilledQuantity  decimal.Decimal `json:"filled_quantity" gorm:"type:numeric(36,18);not null;default:0"`
+	SliceFilled     decimal.Decimal `json:"-" gorm:"type:numeric(36,18);not null;default:0"` // Quantidade executada da parte exibida atual das ordens iceberg, zerada a cada reposição
+	OrderKind       int             `json:"order_kind" gorm:"not null;default:1"`
+	TimeInForce     int             `json:"time_in_force" gorm:"not null;default:1"`
+	ExpiresAt       *time.Time      `json:"expires_at,omitempty"` // Obrigatório apenas para GTD
+	CloseReason     string          `json:"close_reason,omitempty"`
+	HeldAmount      decimal.Decimal `json:"held_amount" gorm:"type:numeric(36,18);not null;default:0"` // Ativo de cotação reservado na compra, ativo base reservado na venda
+	TypeOrder       int             `json:"type_order"`
+	Status          int             `json:"status,omitempty"`
+	LinkedOrderId   *uuid.UUID      `json:"linked_order_id,omitempty" gorm:"type:uuid"` // Outra ordem do par OCO
+	CreatedAt       time.Time       `json:"created_at" gorm:"default:now()"`
+	PriorityAt      *time.Time      `json:"priority_at,omitempty"`                           // Quando a ordem perdeu a prioridade por uma alteração ou reabertura
+	NotionalLimit   decimal.Decimal `json:"-" gorm:"type:numeric(36,18);not null;default:0"` // Limite da faixa de score do dono, conferido pelo motor nas ordens a mercado e stop a mercado
+
+	Client Client `gorm:"foreignKey:OwnerOrderId;references:Id" json:"-"` // Relacionamento, nunca recebido no corpo da requisição
+}
+
+// Notional returns the total value in the quote asset of the order at its limit price.
+func (o Orders) Notional() decimal.Decimal {
+	return o.Price.Mul(o.Quantity)
+}
+
+// BaseAsset returns the asset the order buys or sells.
+func (o Orders) BaseAsset() string {
+	base, _ := MarketAssets(o.Market)
+	return base
+}
 
-	Client Client `gorm:"foreignKey:OwnerOrderId;references:Id" json:"client"` // Relacionamento
+// QuoteAsset returns the asset the order pays or receives.
+func (o Orders) QuoteAsset() string {
+	_, quote := MarketAssets(o.Market)
+	return quote
+}
+
+// HoldAsset returns the asset held by the order: the quote asset for buy orders,
+// the base asset for sell orders.
+func (o Orders) HoldAsset() string {
+	if o.TypeOrder == BUY {
+		return o.QuoteAsset()
+	}
+	return o.BaseAsset()
+}
+
+// RemainingQuantity returns the amount of the base asset that was not filled yet.
+func (o Orders) RemainingQuantity() decimal.Decimal {
+	return o.Quantity.Sub(o.FilledQuantity)
+}
+
+// IsIceberg reports whether the order shows only a slice of its quantity in the book.
+func (o Orders) IsIceberg() bool {
+	return o.DisplayQuantity.IsPositive()
+}
+
+// VisibleQuantity returns the part of the remaining quantity shown in the book: all
+// of it, or for iceberg orders what is left of the current slice. Only fills against
+// the order while it rests consume the slice, so an order partially filled on arrival
+// still shows a whole DisplayQuantity once it rests.
+func (o Orders) VisibleQuantity() decimal.Decimal {
+	remaining := o.RemainingQuantity()
+	if !o.IsIceberg() {
+		return remaining
+	}
+
+	return decimal.Min(o.DisplayQuantity.Sub(o.SliceFilled), remaining)
+}
+
+// Replenishes reports whether filling the given quantity uses up the slice shown by
+// an iceberg order while hidden quantity remains, so a new slice is shown with a new
+// time priority.
+func (o Orders) Replenishes(quantity decimal.Decimal) bool {
+	return o.IsIceberg() && quantity.GreaterThanOrEqual(o.VisibleQuantity()) && o.RemainingQuantity().GreaterThan(quantity)
+}
+
+// HoldFor returns the amount that must be held to cover the given quantity: the
+// quote asset at the limit price for buy orders, the base asset for sell orders.
+func (o Orders) HoldFor(quantity decimal.Decimal) decimal.Decimal {
+	if o.TypeOrder == BUY {
+		return quantity.Mul(o.Price)
+	}
+	return quantity
+}
+
+// HoldConsumedBy returns the part of the held amount released by filling the given quantity.
+// The last fill releases everything that is still held.
+func (o Orders) HoldConsumedBy(quantity decimal.Decimal) decimal.Decimal {
+	if o.StatusAfterFill(quantity) == DONE {
+		return o.HeldAmount
+	}
+	return o.HoldFor(quantity)
+}
+
+// IsMarket reports whether the order executes at the prices of the book instead of
+// a limit price, as market orders and stop-market orders do.
+func (o Orders) IsMarket() bool {
+	return o.OrderKind == MARKET || o.OrderKind == STOP_MARKET
+}
+
+// IsStop reports whether the order only enters the book once its stop price is reached.
+func (o Orders) IsStop() bool {
+	return o.OrderKind == STOP_LIMIT || o.OrderKind == STOP_MARKET
+}
+
+// StopTriggered reports whether the last execution price of the market reached the
+// stop price: at or above it for buy orders, at or below it for sell orders.
+func (o Orders) StopTriggered(lastPrice decimal.Decimal) bool {
+	if o.TypeOrder == BUY {
+		return lastPrice.GreaterThanOrEqual(o.StopPrice)
+	}
+	return lastPrice.LessThanOrEqual(o.StopPrice)
+}
+
+// Triggered returns the stop order converted into the limit or market order it
+// places once triggered, OPEN. The stop price is kept as a record of the trigger.
+func (o Orders) Triggered() Orders {
+	if o.OrderKind == STOP_MARKET {
+		o.OrderKind = MARKET
+	} else {
+		o.OrderKind = LIMIT
+	}
+	o.Status = OPEN
+	return o
+}
+
+// CanRest reports whether the unfilled remainder of the order may stay in the book
+// after matching. Market, IOC and FOK orders never rest.
+func (o Orders) CanRest() bool {
+	return o.OrderKind != MARKET && o.TimeInForce != IOC && o.TimeInForce != FOK
+}
+
+// IsResting reports whether the order is still in the book, or waiting to enter it,
+// holding funds.
+func (o Orders) IsResting() bool {
+	return o.Status == OPEN || o.Status == WAITING || o.Status == PARTIALLY_FILLED || o.Status == PENDING_TRIGGER
+}
+
+// AfterMakerFill returns the resting order as it is after the given quantity is
+// filled against it: the fill consumes the slice shown by an iceberg order, and a
+// slice used up is replaced by a whole new one.
+func (o Orders) AfterMakerFill(quantity decimal.Decimal) Orders {
+	if o.IsIceberg() {
+		if o.Replenishes(quantity) {
+			o.SliceFilled = decimal.Zero
+		} else {
+			o.SliceFilled = o.SliceFilled.Add(quantity)
+		}
+	}
+	return o.AfterFill(quantity)
+}
+
+// AfterFill returns the order as it is after the given quantity is filled, mirroring
+// what the repository persists for each execution.
+func (o Orders) AfterFill(quantity decimal.Decimal) Orders {
+	o.HeldAmount = o.HeldAmount.Sub(o.HoldConsumedBy(quantity))
+	o.Status = o.StatusAfterFill(quantity)
+	o.FilledQuantity = o.FilledQuantity.Add(quantity)
+	if o.Status == DONE {
+		o.CloseReason = CloseReasonFilled
+	}
+	return o
+}
+
+// Cancelled returns the order as it is after leaving the book cancelled for the
+// given reason, with nothing held.
+func (o Orders) Cancelled(reason string) Orders {
+	o.Status = CANCEL
+	o.CloseReason = reason
+	o.HeldAmount = decimal.Zero
+	return o
+}
+
+// Crosses reports whether the order can be matched against the resting order on
+// the other side of the book. A market order without a protection price crosses any price.
+func (o Orders) Crosses(resting Orders) bool {
+	if o.OrderKind == MARKET && !o.Price.IsPositive() {
+		return true
+	}
+	if o.TypeOrder == BUY {
+		return resting.Price.LessThanOrEqual(o.Price)
+	}
+	return resting.Price.GreaterThanOrEqual(o.Price)
+}
+
+// IsExpired reports whether a GTD order passed its expiry.
+func (o Orders) IsExpired(now time.Time) bool {
+	return o.ExpiresAt != nil && !o.ExpiresAt.After(now)
+}
+
+// StatusTransition applies the status transition rules to the order. It returns the
+// status the order must assume, or inEffect when the order already has it.
+func (o Orders) StatusTransition(status int) (newStatus int, inEffect bool, err error) {
+	if o.Status == DONE {
+		return 0, false, ErrorInvalidUpdateOrderDone
+	}
+
+	if o.Status == CANCEL {
+		return 0, false, ErrorInvalidUpdateOrderCancel
+	}
+
+	if o.Status == status || (o.Status == PARTIALLY_FILLED && status == OPEN) {
+		return o.Status, true, nil
+	}
+
+	if o.Status == PENDING_TRIGGER && status != CANCEL {
+		return 0, false, ErrorInvalidUpdateStopOrder
+	}
+
+	if o.Status == WAITING && status != OPEN && status != CANCEL {
+		return 0, false, ErrorInvalidUpdateOrderWaiting
+	}
+
+	if o.Status == WAITING && status == OPEN && o.FilledQuantity.IsPositive() {
+		status = PARTIALLY_FILLED
+	}
+
+	return status, false, nil
+}
+
+// QueuedAt returns when the order took its place in the queue of its price level: when
+// it last lost its time priority, or when it was created.
+func (o Orders) QueuedAt() time.Time {
+	if o.PriorityAt != nil {
+		return *o.PriorityAt
+	}
+	return o.CreatedAt
+}
+
+// Reopens reports whether going to status takes a WAITING order back to the book,
+// where it queues behind the orders already at its price.
+func (o Orders) Reopens(status int) bool {
+	return o.Status == WAITING && (status == OPEN || status == PARTIALLY_FILLED)
+}
+
+// Amend returns the order with the new price and quantity and the hold they require.
+// keepsPriority is false when the price changes or the quantity increases, which
+// sends the order to the back of its price level showing a whole new slice.
+func (o Orders) Amend(price, quantity decimal.Decimal) (amended Orders, keepsPriority bool, err error) {
+	if !o.IsResting() || o.Status == PENDING_TRIGGER {
+		return o, false, ErrorInvalidAmendOrderStatus
+	}
+
+	if !quantity.GreaterThan(o.FilledQuantity) {
+		return o, false, ErrorInvalidAmendQuantity
+	}
+
+	keepsPriority = price.Equal(o.Price) && quantity.LessThanOrEqual(o.Quantity)
+
+	o.Price = price
+	o.Quantity = quantity
+	o.HeldAmount = o.HoldFor(o.RemainingQuantity())
+	if !keepsPriority {
+		o.SliceFilled = decimal.Zero
+	}
+
+	return o, keepsPriority, nil
+}
+
+// StatusAfterFill returns the status the order must assume after the given quantity is filled.
+func (o Orders) StatusAfterFill(quantity decimal.Decimal) int {
+	if o.FilledQuantity.Add(quantity).GreaterThanOrEqual(o.Quantity) {
+		return DONE
+	}
+	return PARTIALLY_FILLED
+}
+
+// Precisões aceitas nos valores enviados pelos clientes. Como preço e quantidade
+// são multiplicados sem arredondamento, todo valor derivado deles é exato.
+const (
+	BRLPrecision = 2 // centavos
+	BTPrecision  = 8 // satoshis
+)
+
+// HasPrecision reports whether the value has at most the given number of decimal places.
+func HasPrecision(value decimal.Decimal, places int32) bool {
+	return value.Equal(value.Truncate(places))
 }
 
 const (
@@ -50,6 +430,8 @@ const (
 	WAITING
 	DONE
 	CANCEL
+	PARTIALLY_FILLED
+	PENDING_TRIGGER // ordem stop guardada fora do livro até o preço de disparo
 )
 
 const (
@@ -57,6 +439,33 @@ const (
 	SELL
 )
 
+const (
+	LIMIT = iota + 1
+	MARKET
+	STOP_LIMIT  // vira LIMIT quando o preço de disparo é atingido
+	STOP_MARKET // vira MARKET quando o preço de disparo é atingido
+)
+
+const (
+	GTC = iota + 1 // Good till cancelled
+	IOC            // Immediate or cancel
+	FOK            // Fill or kill
+	GTD            // Good till date
+)
+
+// Motivos registrados quando uma ordem sai do livro (DONE ou CANCEL).
+const (
+	CloseReasonFilled    = "FILLED"               // totalmente executada
+	CloseReasonRequested = "REQUESTED_BY_CLIENT"  // status alterado pela rota de status
+	CloseReasonUnfilled  = "UNFILLED_REMAINDER"   // restante de MARKET, IOC ou FOK que não pode ficar no livro
+	CloseReasonExpired   = "EXPIRED"              // GTD que passou de expires_at
+	CloseReasonStale     = "STALE"                // ficou no livro mais tempo que o permitido
+	CloseReasonCancelAll = "CANCEL_ALL"           // cancelada junto com as demais ordens do cliente
+	CloseReasonNoBalance = "INSUFFICIENT_BALANCE" // stop a mercado disparada sem saldo para a reserva
+	CloseReasonOco       = "OCO"                  // a outra ordem do par OCO foi executada ou saiu do livro
+	CloseReasonOverLimit = "NOTIONAL_LIMIT"       // stop a mercado disparada com valor acima do limite da faixa de score
+)
+
 func TranslateStatus(status int) string {
 	switch status {
 	case 1:
@@ -67,6 +476,10 @@ func TranslateStatus(status int) string {
 		return "DONE"
 	case 4:
 		return "CANCEL"
+	case 5:
+		return "PARTIALLY_FILLED"
+	case 6:
+		return "PENDING_TRIGGER"
 	default:
 		return "status not found"
 	}
@@ -83,6 +496,36 @@ func TranslateTypeOrder(status int) string {
 	}
 }
 
+func TranslateOrderKind(kind int) string {
+	switch kind {
+	case 1:
+		return "LIMIT"
+	case 2:
+		return "MARKET"
+	case 3:
+		return "STOP_LIMIT"
+	case 4:
+		return "STOP_MARKET"
+	default:
+		return "order_kind not found"
+	}
+}
+
+func TranslateTimeInForce(timeInForce int) string {
+	switch timeInForce {
+	case 1:
+		return "GTC"
+	case 2:
+		return "IOC"
+	case 3:
+		return "FOK"
+	case 4:
+		return "GTD"
+	default:
+		return "time_in_force not found"
+	}
+}
+
 type Error struct {
 	Message    string
 	Kind       ErrorKind
@@ -93,6 +536,12 @@ func (e Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
 }
 
+// Retryable reports whether the same request may succeed if it is sent again,
+// as with a conflict with a concurrent change.
+func (e Error) Retryable() bool {
+	return e.Kind == ErrorKindConflict
+}
+
 type ErrorKind string
 
 func NewError(kind ErrorKind, message string, statusCode int) Error {
@@ -106,6 +555,7 @@ const (
 	ErrorKindForbidden    ErrorKind = "FORBIDDEN"
 	ErrorKindInternal     ErrorKind = "INTERNAL_SERVER_ERROR"
 	ErrorKindDatabase     ErrorKind = "DATABASE_ERROR"
+	ErrorKindConflict     ErrorKind = "CONFLICT"
 )
 
 const (
@@ -122,15 +572,77 @@ const (
 	StatusCodeBadRequest   int = 400
 	StatusCodeForbidden    int = 403
 	StatusCodeInternal     int = 500
+	StatusCodeConflict     int = 409
+	StatusCodeCreated      int = 201
 )
 
 var (
-	ErrorNotFound                  = NewError(ErrorKindNotFound, ErrorMessageNotFound, StatusCodeNotFound)
-	ErrorInvalidTypeOrder          = NewError(ErrorKindInvalidInput, "invalid type_order", StatusCodeInvalidInput)
-	ErrorInvalidStatus             = NewError(ErrorKindInvalidInput, "invalid status", StatusCodeInvalidInput)
-	ErrorInvalidPriceOrder         = NewError(ErrorKindInvalidInput, "It is not allowed to create orders with a price less than or equal to 0", StatusCodeInvalidInput)
-	ErrorInvalidUpdateOrderDone    = NewError(ErrorKindInvalidInput, "invalid update, this order was done", StatusCodeInvalidInput)
-	ErrorInvalidUpdateOrderCancel  = NewError(ErrorKindInvalidInput, "invalid update, this order was cancel", StatusCodeInvalidInput)
-	ErrorInvalidUpdateOrderWaiting = NewError(ErrorKindInvalidInput, "invalid update, An order waiting only change status to OPEN or CANCEL", StatusCodeInvalidInput)
-	ErrorInsufficientBalance       = NewError(ErrorKindInvalidInput, "insufficient balance", StatusCodeInvalidInput)
+	ErrorNotFound                   = NewError(ErrorKindNotFound, ErrorMessageNotFound, StatusCodeNotFound)
+	ErrorInvalidTypeOrder           = NewError(ErrorKindInvalidInput, "invalid type_order", StatusCodeInvalidInput)
+	ErrorInvalidStatus              = NewError(ErrorKindInvalidInput, "invalid status", StatusCodeInvalidInput)
+	ErrorInvalidPriceOrder          = NewError(ErrorKindInvalidInput, "It is not allowed to create orders with a price less than or equal to 0", StatusCodeInvalidInput)
+	ErrorInvalidQuantityOrder       = NewError(ErrorKindInvalidInput, "It is not allowed to create orders with a quantity less than or equal to 0", StatusCodeInvalidInput)
+	ErrorInvalidPriceTick           = NewError(ErrorKindInvalidInput, "invalid price, it must be a multiple of the market tick_size", StatusCodeInvalidInput)
+	ErrorInvalidQuantityLot         = NewError(ErrorKindInvalidInput, "invalid quantity, it must be a multiple of the market lot_size", StatusCodeInvalidInput)
+	ErrorOrderBelowMinNotional      = NewError(ErrorKindInvalidInput, "order value (price * quantity) is below the market min_notional", StatusCodeInvalidInput)
+	ErrorInvalidOrderKind           = NewError(ErrorKindInvalidInput, "invalid order_kind", StatusCodeInvalidInput)
+	ErrorInvalidMarketOrderStatus   = NewError(ErrorKindInvalidInput, "invalid status, market and stop orders can only be created as OPEN", StatusCodeInvalidInput)
+	ErrorInsufficientLiquidity      = NewError(ErrorKindInvalidInput, "insufficient liquidity in the book to fill the market order", StatusCodeInvalidInput)
+	ErrorInvalidStopPrice           = NewError(ErrorKindInvalidInput, "invalid stop_price, stop orders require a stop_price greater than 0", StatusCodeInvalidInput)
+	ErrorInvalidStopPriceTick       = NewError(ErrorKindInvalidInput, "invalid stop_price, it must be a multiple of the market tick_size", StatusCodeInvalidInput)
+	ErrorStopPriceWithoutStop       = NewError(ErrorKindInvalidInput, "invalid stop_price, only stop orders accept a stop_price", StatusCodeInvalidInput)
+	ErrorInvalidStopTimeInForce     = NewError(ErrorKindInvalidInput, "invalid time_in_force, stop orders can only be GTC or GTD", StatusCodeInvalidInput)
+	ErrorInvalidDisplayQuantity     = NewError(ErrorKindInvalidInput, "invalid display_quantity, it must be greater than 0 and less than quantity", StatusCodeInvalidInput)
+	ErrorInvalidDisplayQuantityLot  = NewError(ErrorKindInvalidInput, "invalid display_quantity, it must be a multiple of the market lot_size", StatusCodeInvalidInput)
+	ErrorInvalidIcebergOrder        = NewError(ErrorKindInvalidInput, "invalid display_quantity, only limit orders that can rest in the book can be iceberg orders", StatusCodeInvalidInput)
+	ErrorInvalidTimeInForce         = NewError(ErrorKindInvalidInput, "invalid time_in_force", StatusCodeInvalidInput)
+	ErrorInvalidTimeInForceStatus   = NewError(ErrorKindInvalidInput, "invalid status, IOC and FOK orders can only be created as OPEN", StatusCodeInvalidInput)
+	ErrorInvalidExpiresAt           = NewError(ErrorKindInvalidInput, "invalid expires_at, GTD orders require an expiry in the future", StatusCodeInvalidInput)
+	ErrorExpiresAtWithoutGTD        = NewError(ErrorKindInvalidInput, "invalid expires_at, only GTD orders accept an expiry", StatusCodeInvalidInput)
+	ErrorFillOrKillNotFillable      = NewError(ErrorKindInvalidInput, "the FOK order cannot be completely filled by the book", StatusCodeInvalidInput)
+	ErrorInvalidBookDepth           = NewError(ErrorKindInvalidInput, "invalid depth, it must be between 1 and 100", StatusCodeInvalidInput)
+	ErrorEngineStopped              = NewError(ErrorKindInternal, "matching engine is not running", StatusCodeInternal)
+	ErrorInvalidTimeRange           = NewError(ErrorKindInvalidInput, "invalid time range, from must be before to", StatusCodeInvalidInput)
+	ErrorInvalidUpdateOrderDone     = NewError(ErrorKindInvalidInput, "invalid update, this order was done", StatusCodeInvalidInput)
+	ErrorInvalidUpdateOrderCancel   = NewError(ErrorKindInvalidInput, "invalid update, this order was cancel", StatusCodeInvalidInput)
+	ErrorInvalidUpdateOrderWaiting  = NewError(ErrorKindInvalidInput, "invalid update, An order waiting only change status to OPEN or CANCEL", StatusCodeInvalidInput)
+	ErrorInvalidUpdateStopOrder     = NewError(ErrorKindInvalidInput, "invalid update, a stop order pending its trigger can only be cancelled", StatusCodeInvalidInput)
+	ErrorInsufficientBalance        = NewError(ErrorKindInvalidInput, "insufficient balance", StatusCodeInvalidInput)
+	ErrorEmptyAmendment             = NewError(ErrorKindInvalidInput, "invalid amendment, inform the new price and/or quantity", StatusCodeInvalidInput)
+	ErrorInvalidAmendOrderStatus    = NewError(ErrorKindInvalidInput, "invalid amendment, only OPEN, WAITING or PARTIALLY_FILLED orders can be amended", StatusCodeInvalidInput)
+	ErrorInvalidAmendQuantity       = NewError(ErrorKindInvalidInput, "invalid quantity, it must be greater than the quantity already filled", StatusCodeInvalidInput)
+	ErrorInvalidPriceRange          = NewError(ErrorKindInvalidInput, "invalid price range, min_price must not be greater than max_price", StatusCodeInvalidInput)
+	ErrorInvalidBatchSize           = NewError(ErrorKindInvalidInput, "invalid batch, it must have between 1 and 100 orders", StatusCodeInvalidInput)
+	ErrorInvalidAtomicBatchOrder    = NewError(ErrorKindInvalidInput, "invalid batch, market and FOK orders cannot be sent in an all-or-nothing batch", StatusCodeInvalidInput)
+	ErrorBatchAborted               = NewError(ErrorKindInvalidInput, "order not created, another order of the all-or-nothing batch was rejected", StatusCodeInvalidInput)
+	ErrorInvalidOcoSize             = NewError(ErrorKindInvalidInput, "invalid oco, it must have exactly 2 orders", StatusCodeInvalidInput)
+	ErrorInvalidOcoOrders           = NewError(ErrorKindInvalidInput, "invalid oco, both orders must have the same owner_order_id, market and type_order", StatusCodeInvalidInput)
+	ErrorInvalidOcoOrderKind        = NewError(ErrorKindInvalidInput, "invalid oco, market, IOC and FOK orders never rest in the book and cannot be linked", StatusCodeInvalidInput)
+	ErrorOrderConflict              = NewError(ErrorKindConflict, "the order was changed by another operation, try again", StatusCodeConflict)
+	ErrorInvalidCreateOrderStatus   = NewError(ErrorKindInvalidInput, "invalid status, an order can only be created as OPEN or WAITING", StatusCodeInvalidInput)
+	ErrorInvalidScore               = NewError(ErrorKindInvalidInput, "invalid score, it must be between 0 and 100", StatusCodeInvalidInput)
+	ErrorInvalidBalance             = NewError(ErrorKindInvalidInput, "It is not allowed to create clients with a negative balance", StatusCodeInvalidInput)
+	ErrorInvalidBalanceBRLPrecision = NewError(ErrorKindInvalidInput, "balance_brl must have at most 2 decimal places (centavos)", StatusCodeInvalidInput)
+	ErrorInvalidBalanceBTPrecision  = NewError(ErrorKindInvalidInput, "balance_bt must have at most 8 decimal places (satoshis)", StatusCodeInvalidInput)
+	ErrorEmptyClientUpdate          = NewError(ErrorKindInvalidInput, "invalid update, inform the new score", StatusCodeInvalidInput)
+	ErrorInvalidAsset               = NewError(ErrorKindInvalidInput, "invalid asset, it is not traded in any market", StatusCodeInvalidInput)
+	ErrorInvalidAmount              = NewError(ErrorKindInvalidInput, "invalid amount, it must be greater than 0", StatusCodeInvalidInput)
+	ErrorInvalidAmountPrecision     = NewError(ErrorKindInvalidInput, "amount must have at most 2 decimal places for BRL and 8 for any other asset, whatever the tick and lot sizes of its markets", StatusCodeInvalidInput)
+	ErrorUnbalancedJournal          = NewError(ErrorKindInternal, "ledger journal does not balance", StatusCodeInternal)
+	ErrorInvalidWithdrawalStatus    = NewError(ErrorKindInvalidInput, "invalid status, it must be REQUESTED, APPROVED, SENT or REJECTED", StatusCodeInvalidInput)
+	ErrorInvalidOperator            = NewError(ErrorKindInvalidInput, "invalid operator_id, inform the operator reviewing the withdrawal", StatusCodeInvalidInput)
+	ErrorInvalidApproveWithdrawal   = NewError(ErrorKindInvalidInput, "invalid update, only REQUESTED withdrawals can be approved", StatusCodeInvalidInput)
+	ErrorInvalidRejectWithdrawal    = NewError(ErrorKindInvalidInput, "invalid update, only REQUESTED or APPROVED withdrawals can be rejected", StatusCodeInvalidInput)
+	ErrorInvalidSendWithdrawal      = NewError(ErrorKindInvalidInput, "invalid update, only APPROVED withdrawals can be sent", StatusCodeInvalidInput)
+	ErrorSameOperatorApproval       = NewError(ErrorKindForbidden, "the second approval must come from a different operator", StatusCodeForbidden)
+	ErrorWithdrawalConflict         = NewError(ErrorKindConflict, "the withdrawal was changed by another operation, try again", StatusCodeConflict)
+	ErrorOrderNotionalLimit         = NewError(ErrorKindForbidden, "the order notional is above the limit of the client's score band", StatusCodeForbidden)
+	ErrorNoNotionalLimit            = NewError(ErrorKindForbidden, "the client's score band has no notional limit for the quote asset of this market", StatusCodeForbidden)
+	ErrorOpenOrdersLimit            = NewError(ErrorKindForbidden, "the client reached the maximum number of open orders of its score band", StatusCodeForbidden)
+	ErrorInvalidPagination          = NewError(ErrorKindInvalidInput, "invalid pagination, page must be at least 1 and page_size between 1 and 100", StatusCodeInvalidInput)
+	ErrorInvalidMarket              = NewError(ErrorKindInvalidInput, "invalid market, it is not listed", StatusCodeInvalidInput)
+	ErrorInvalidMarketAsset         = NewError(ErrorKindInvalidInput, "invalid asset, base_asset and quote_asset must be different codes of 2 to 10 uppercase letters or digits", StatusCodeInvalidInput)
+	ErrorInvalidMarketSizes         = NewError(ErrorKindInvalidInput, "invalid market, tick_size and lot_size must be greater than 0 and min_notional must not be negative", StatusCodeInvalidInput)
+	ErrorInvalidMarketQuoteAsset    = NewError(ErrorKindInvalidInput, "invalid quote_asset, the score bands have no notional limit for it", StatusCodeInvalidInput)
+	ErrorMarketAlreadyListed        = NewError(ErrorKindInvalidInput, "invalid market, it is already listed", StatusCodeInvalidInput)
 )
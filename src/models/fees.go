@@ -0,0 +1,30 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// FeeSchedule holds the rates charged on each execution, as a fraction of what the
+// client receives: the buyer pays in the base asset and the seller in the quote asset.
+// The maker is the order that was resting in the book, the taker the incoming order
+// that crossed it.
+type FeeSchedule struct {
+	MakerRate decimal.Decimal
+	TakerRate decimal.Decimal
+}
+
+// Discounted returns the schedule with both rates reduced by the given fraction.
+func (f FeeSchedule) Discounted(discount decimal.Decimal) FeeSchedule {
+	remaining := decimal.NewFromInt(1).Sub(discount)
+	return FeeSchedule{
+		MakerRate: f.MakerRate.Mul(remaining),
+		TakerRate: f.TakerRate.Mul(remaining),
+	}
+}
+
+// Fee returns the fee charged on amount of the asset, rounded to the precision of the asset.
+func (f FeeSchedule) Fee(amount decimal.Decimal, asset string, maker bool) decimal.Decimal {
+	rate := f.TakerRate
+	if maker {
+		rate = f.MakerRate
+	}
+	return amount.Mul(rate).Round(AssetPrecision(asset))
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Ativos do mercado padrão. Outros ativos passam a existir quando um mercado que os
+// negocia é listado.
+const (
+	AssetBRL = "BRL"
+	AssetBT  = "BT"
+)
+
+// Contas do razão. Cada cliente tem uma conta disponível e uma reservada por ativo;
+// a conta externa é a contrapartida de todo valor que entra ou sai da corretora e a
+// conta de taxas recebe as taxas cobradas nas negociações.
+const (
+	AccountAvailable = "AVAILABLE"
+	AccountHeld      = "HELD"
+	AccountExternal  = "EXTERNAL"
+	AccountFees      = "FEES"
+)
+
+// Tipos de lançamento (journal).
+const (
+	JournalOpening    = "OPENING"    // saldo do cliente antes do razão existir ou no cadastro
+	JournalDeposit    = "DEPOSIT"    // depósito
+	JournalWithdrawal = "WITHDRAWAL" // saque
+	JournalHold       = "HOLD"       // reserva de saldo por uma ordem
+	JournalRelease    = "RELEASE"    // devolução do saldo reservado por uma ordem
+	JournalTrade      = "TRADE"      // liquidação de uma negociação
+)
+
+// AssetPrecision returns how many decimal places the asset accepts: centavos for BRL
+// and satoshis for any other asset.
+func AssetPrecision(asset string) int32 {
+	if asset == AssetBRL {
+		return BRLPrecision
+	}
+	return BTPrecision
+}
+
+// LedgerAccount identifies an account of the ledger. ClientId is nil for the
+// accounts of the exchange itself.
+type LedgerAccount struct {
+	ClientId *uuid.UUID
+	Type     string
+}
+
+func AvailableAccount(clientId uuid.UUID) LedgerAccount {
+	return LedgerAccount{ClientId: &clientId, Type: AccountAvailable}
+}
+
+func HeldAccount(clientId uuid.UUID) LedgerAccount {
+	return LedgerAccount{ClientId: &clientId, Type: AccountHeld}
+}
+
+func ExternalAccount() LedgerAccount {
+	return LedgerAccount{Type: AccountExternal}
+}
+
+func FeesAccount() LedgerAccount {
+	return LedgerAccount{Type: AccountFees}
+}
+
+// Journal groups the entries of one movement. The entries of each asset always sum
+// to zero: whatever leaves an account enters another.
+type Journal struct {
+	Id        uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind      string        `gorm:"not null" json:"kind"`
+	Reference *uuid.UUID    `gorm:"type:uuid;index" json:"reference,omitempty"` // Ordem ou negociação que originou o lançamento
+	CreatedAt time.Time     `gorm:"default:now()" json:"created_at"`
+	Entries   []LedgerEntry `gorm:"foreignKey:JournalId" json:"entries"`
+}
+
+// LedgerEntry is the change of one account by one journal. A positive amount
+// increases the balance of the account, a negative one decreases it.
+type LedgerEntry struct {
+	Id        uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	JournalId uuid.UUID       `gorm:"type:uuid;not null;index" json:"journal_id"`
+	ClientId  *uuid.UUID      `gorm:"type:uuid;index" json:"client_id,omitempty"`
+	Account   string          `gorm:"not null" json:"account"`
+	Asset     string          `gorm:"not null" json:"asset"`
+	Amount    decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"amount"`
+	CreatedAt time.Time       `gorm:"default:now()" json:"created_at"`
+}
+
+func NewJournal(kind string, reference *uuid.UUID) Journal {
+	return Journal{Id: uuid.New(), Kind: kind, Reference: reference, CreatedAt: time.Now()}
+}
+
+// Transfer moves amount of the asset from one account to another. Zero amounts are
+// left out of the journal.
+func (j *Journal) Transfer(asset string, amount decimal.Decimal, from, to LedgerAccount) {
+	if amount.IsZero() {
+		return
+	}
+
+	j.Entries = append(j.Entries, j.entry(asset, amount.Neg(), from), j.entry(asset, amount, to))
+}
+
+func (j Journal) entry(asset string, amount decimal.Decimal, account LedgerAccount) LedgerEntry {
+	return LedgerEntry{
+		Id:        uuid.New(),
+		JournalId: j.Id,
+		ClientId:  account.ClientId,
+		Account:   account.Type,
+		Asset:     asset,
+		Amount:    amount,
+		CreatedAt: j.CreatedAt,
+	}
+}
+
+// Balanced reports whether the entries of every asset sum to zero.
+func (j Journal) Balanced() bool {
+	sums := map[string]decimal.Decimal{}
+	for _, e := range j.Entries {
+		sums[e.Asset] = sums[e.Asset].Add(e.Amount)
+	}
+
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// NewOpeningJournal records the balances a client already has, available and held,
+// as coming from outside the exchange.
+func NewOpeningJournal(client Client) Journal {
+	journal := NewJournal(JournalOpening, &client.Id)
+	for _, balance := range client.Balances {
+		journal.Transfer(balance.Asset, balance.Available, ExternalAccount(), AvailableAccount(client.Id))
+		journal.Transfer(balance.Asset, balance.Held, ExternalAccount(), HeldAccount(client.Id))
+	}
+	return journal
+}
+
+// LedgerMovementDtoInput is a deposit or a withdrawal.
+type LedgerMovementDtoInput struct {
+	Asset  string          `json:"asset"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// LedgerPageDtoOutput is one page of the ledger entries of a client, newest first.
+type LedgerPageDtoOutput struct {
+	Entries  []LedgerEntry `json:"entries"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int64         `json:"total"`
+}
+
+// LedgerMismatch is a client balance that differs from the sum of its ledger account.
+type LedgerMismatch struct {
+	ClientId uuid.UUID       `json:"client_id"`
+	Account  string          `json:"account"`
+	Asset    string          `json:"asset"`
+	Balance  decimal.Decimal `json:"balance"` // Saldo registrado no cliente
+	Ledger   decimal.Decimal `json:"ledger"`  // Soma dos lançamentos da conta
+}
+
+// Balance returns the balance the client keeps for one of its ledger accounts.
+func (c Client) Balance(account, asset string) decimal.Decimal {
+	for _, b := range c.Balances {
+		if b.Asset == asset {
+			return b.Of(account)
+		}
+	}
+	return decimal.Zero
+}
+
+// Of returns the balance of one of the ledger accounts of the asset.
+func (b Balance) Of(account string) decimal.Decimal {
+	switch account {
+	case AccountAvailable:
+		return b.Available
+	case AccountHeld:
+		return b.Held
+	default:
+		return decimal.Zero
+	}
+}
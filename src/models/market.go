@@ -0,0 +1,82 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultMarket é o mercado das propostas que não informam outro, e de todas as
+// propostas criadas antes de existirem mercados.
+const DefaultMarket = "BT-BRL"
+
+// Market is a trading pair: its orders buy and sell the base asset paying with the
+// quote asset. The symbol is the base and the quote assets joined by a dash, so the
+// assets of an order are known from the market it references.
+type Market struct {
+	Symbol      string          `gorm:"primaryKey" json:"symbol"`
+	BaseAsset   string          `gorm:"not null" json:"base_asset"`
+	QuoteAsset  string          `gorm:"not null" json:"quote_asset"`
+	TickSize    decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"tick_size"`              // Menor variação do preço
+	LotSize     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"lot_size"`               // Menor variação da quantidade
+	MinNotional decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"min_notional"` // Menor valor de uma proposta no ativo de cotação
+	CreatedAt   time.Time       `gorm:"default:now()" json:"created_at"`
+}
+
+// MarketDtoInput lists a new market.
+type MarketDtoInput struct {
+	BaseAsset   string          `json:"base_asset"`
+	QuoteAsset  string          `json:"quote_asset"`
+	TickSize    decimal.Decimal `json:"tick_size"`
+	LotSize     decimal.Decimal `json:"lot_size"`
+	MinNotional decimal.Decimal `json:"min_notional"`
+}
+
+// OnTick reports whether the price is a multiple of the tick size of the market.
+func (m Market) OnTick(price decimal.Decimal) bool {
+	return price.Mod(m.TickSize).IsZero()
+}
+
+// OnLot reports whether the quantity is a multiple of the lot size of the market.
+func (m Market) OnLot(quantity decimal.Decimal) bool {
+	return quantity.Mod(m.LotSize).IsZero()
+}
+
+// MeetsMinNotional reports whether the value of an order, in the quote asset, is at
+// least the minimum notional of the market.
+func (m Market) MeetsMinNotional(notional decimal.Decimal) bool {
+	return notional.GreaterThanOrEqual(m.MinNotional)
+}
+
+// MarketSymbol returns the symbol of the market of the given assets.
+func MarketSymbol(base, quote string) string {
+	return base + "-" + quote
+}
+
+// MarketAssets returns the base and the quote assets of the market with the given symbol.
+func MarketAssets(symbol string) (base, quote string) {
+	base, quote, _ = strings.Cut(symbol, "-")
+	return base, quote
+}
+
+var assetCode = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+
+// ValidAsset reports whether the asset code has 2 to 10 uppercase letters or digits.
+func ValidAsset(asset string) bool {
+	return assetCode.MatchString(asset)
+}
+
+// DefaultMarkets are listed when the database is created. BT-BRL keeps the precision
+// the exchange always had: centavos for the price and satoshis for the quantity.
+var DefaultMarkets = []Market{
+	{
+		Symbol:      DefaultMarket,
+		BaseAsset:   AssetBT,
+		QuoteAsset:  AssetBRL,
+		TickSize:    decimal.New(1, -BRLPrecision),
+		LotSize:     decimal.New(1, -BTPrecision),
+		MinNotional: decimal.Zero,
+	},
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// OcoOrderCount is how many orders an OCO (one-cancels-other) group links.
+const OcoOrderCount = 2
+
+// OrderOcoDtoInput links two orders of the same client, market and side, such as a
+// take-profit and a stop-loss: a fill of either one, even partial, or its leaving the
+// book cancels the other.
+type OrderOcoDtoInput struct {
+	Orders []Orders `json:"orders"`
+}
+
+// SharedHoldChange returns how much the hold of an OCO pair changes when one of its
+// orders goes from holding before to holding after while the other one holds other.
+// Only one order of the pair can ever be filled, so the pair holds the larger of the
+// two amounts instead of their sum.
+func SharedHoldChange(before, after, other decimal.Decimal) decimal.Decimal {
+	return decimal.Max(after, other).Sub(decimal.Max(before, other))
+}
@@ -0,0 +1,86 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// ScoreBand is what clients with at least MinScore pay and are allowed to do.
+type ScoreBand struct {
+	MinScore      int
+	FeeDiscount   decimal.Decimal            // Fração da taxa que não é cobrada
+	MaxNotional   map[string]decimal.Decimal // Valor máximo de uma proposta por ativo de cotação
+	MaxOpenOrders int64                      // Propostas OPEN, WAITING, PARTIALLY_FILLED ou PENDING_TRIGGER ao mesmo tempo
+}
+
+// NotionalLimit returns the maximum value of an order priced in the given quote
+// asset, and false when the band has no limit for it.
+func (b ScoreBand) NotionalLimit(quote string) (decimal.Decimal, bool) {
+	limit, ok := b.MaxNotional[quote]
+	return limit, ok
+}
+
+// ScorePolicy maps the score of a client to its band. Bands are ordered from the
+// highest MinScore to the lowest, and the last one covers every score.
+type ScorePolicy []ScoreBand
+
+// For returns the band of the given score.
+func (p ScorePolicy) For(score int) ScoreBand {
+	for _, band := range p {
+		if score >= band.MinScore {
+			return band
+		}
+	}
+	return p[len(p)-1]
+}
+
+// Covers reports whether every band has a notional limit for the quote asset, so
+// orders of a market quoted in it can be checked whatever the score of the client.
+func (p ScorePolicy) Covers(quote string) bool {
+	for _, band := range p {
+		if _, ok := band.NotionalLimit(quote); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// AssetUSDT é o dólar digital, ativo de cotação que já tem limites nas faixas de score
+// padrão, além dos ativos do mercado padrão.
+const AssetUSDT = "USDT"
+
+// DefaultScorePolicy limits orders quoted in BRL, BT and USDT. The BT and USDT limits
+// are about the BRL ones at 1 BT = 330.000 BRL and 1 USDT = 5 BRL.
+var DefaultScorePolicy = ScorePolicy{
+	{MinScore: 90, FeeDiscount: decimal.RequireFromString("0.5"), MaxNotional: notionalLimits("1000000", "3", "200000"), MaxOpenOrders: 100},
+	{MinScore: 70, FeeDiscount: decimal.RequireFromString("0.25"), MaxNotional: notionalLimits("250000", "0.75", "50000"), MaxOpenOrders: 50},
+	{MinScore: 50, FeeDiscount: decimal.RequireFromString("0.1"), MaxNotional: notionalLimits("100000", "0.3", "20000"), MaxOpenOrders: 20},
+	{MinScore: MinScore, FeeDiscount: decimal.Zero, MaxNotional: notionalLimits("20000", "0.06", "4000"), MaxOpenOrders: 5},
+}
+
+func notionalLimits(brl, bt, usdt string) map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		AssetBRL:  decimal.RequireFromString(brl),
+		AssetBT:   decimal.RequireFromString(bt),
+		AssetUSDT: decimal.RequireFromString(usdt),
+	}
+}
+
+// WithNotionalLimits returns a copy of the policy with the notional limits of the quote
+// asset set to the given ones, one per band from the highest MinScore to the lowest.
+// The policy is returned unchanged when the number of limits does not match its bands.
+func (p ScorePolicy) WithNotionalLimits(quote string, limits []decimal.Decimal) ScorePolicy {
+	if len(limits) != len(p) {
+		return p
+	}
+
+	policy := make(ScorePolicy, len(p))
+	for i, band := range p {
+		maxNotional := make(map[string]decimal.Decimal, len(band.MaxNotional)+1)
+		for asset, limit := range band.MaxNotional {
+			maxNotional[asset] = limit
+		}
+		maxNotional[quote] = limits[i]
+
+		band.MaxNotional = maxNotional
+		policy[i] = band
+	}
+	return policy
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Trade is one execution between a buy and a sell order. The maker is the order
+// that was resting in the book, the taker is the incoming order that crossed it.
+type Trade struct {
+	Id           uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id,omitempty"`
+	Market       string          `gorm:"not null;default:'BT-BRL';index" json:"market"`
+	BuyOrderId   uuid.UUID       `gorm:"type:uuid;not null;index" json:"buy_order_id"`
+	SellOrderId  uuid.UUID       `gorm:"type:uuid;not null;index" json:"sell_order_id"`
+	BuyerId      uuid.UUID       `gorm:"type:uuid;not null;index" json:"buyer_id"`
+	SellerId     uuid.UUID       `gorm:"type:uuid;not null;index" json:"seller_id"`
+	MakerOrderId uuid.UUID       `gorm:"type:uuid;not null" json:"maker_order_id"`
+	TakerOrderId uuid.UUID       `gorm:"type:uuid;not null" json:"taker_order_id"`
+	TakerSide    int             `json:"taker_side"`
+	Price        decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"price"`                // Preço de execução no ativo de cotação
+	Quantity     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"quantity"`             // Quantidade executada do ativo base
+	Notional     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"notional"`             // Valor executado no ativo de cotação
+	BuyerFee     decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"buyer_fee"`  // Taxa do comprador no ativo base
+	SellerFee    decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"seller_fee"` // Taxa do vendedor no ativo de cotação
+	CreatedAt    time.Time       `gorm:"default:now();index" json:"created_at"`
+}
+
+type TradeDtoOutput struct {
+	Id           uuid.UUID       `json:"id"`
+	Market       string          `json:"market"`
+	BuyOrderId   uuid.UUID       `json:"buy_order_id"`
+	SellOrderId  uuid.UUID       `json:"sell_order_id"`
+	BuyerId      uuid.UUID       `json:"buyer_id"`
+	SellerId     uuid.UUID       `json:"seller_id"`
+	MakerOrderId uuid.UUID       `json:"maker_order_id"`
+	TakerOrderId uuid.UUID       `json:"taker_order_id"`
+	TakerSide    string          `json:"taker_side"`
+	Price        decimal.Decimal `json:"price"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Notional     decimal.Decimal `json:"notional"`
+	BuyerFee     decimal.Decimal `json:"buyer_fee"`
+	SellerFee    decimal.Decimal `json:"seller_fee"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// TradeFilter narrows the trade history. Zero values mean no filter.
+type TradeFilter struct {
+	Market   string
+	ClientId string
+	From     *time.Time
+	To       *time.Time
+}
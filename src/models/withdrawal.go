@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Status do saque.
+const (
+	WithdrawalRequested = "REQUESTED" // aguardando aprovação, o valor fica reservado
+	WithdrawalApproved  = "APPROVED"  // aprovado, aguardando o envio
+	WithdrawalSent      = "SENT"      // enviado, o valor saiu da corretora
+	WithdrawalRejected  = "REJECTED"  // rejeitado, o valor voltou para o saldo disponível
+)
+
+// withdrawalApprovalThresholds are the largest amounts, per asset, a single operator
+// can approve. Anything above needs a second operator, as does any withdrawal of an
+// asset without a threshold.
+var withdrawalApprovalThresholds = map[string]decimal.Decimal{
+	AssetBRL: decimal.NewFromInt(50000),
+	AssetBT:  decimal.NewFromInt(1),
+}
+
+// WithdrawalApprovalThreshold returns the largest amount of the asset a single operator can approve.
+func WithdrawalApprovalThreshold(asset string) decimal.Decimal {
+	return withdrawalApprovalThresholds[asset]
+}
+
+// Withdrawal is a request to move funds out of the exchange. The amount is held from
+// the available balance when requested and only leaves the ledger when sent.
+type Withdrawal struct {
+	Id               uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	ClientId         uuid.UUID       `gorm:"type:uuid;not null;index" json:"client_id"`
+	Asset            string          `gorm:"not null" json:"asset"`
+	Amount           decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"amount"`
+	Status           string          `gorm:"not null;index" json:"status"`
+	ApprovedBy       string          `json:"approved_by,omitempty"`
+	SecondApprovedBy string          `json:"second_approved_by,omitempty"` // Obrigatório acima do limite de aprovação
+	RejectedBy       string          `json:"rejected_by,omitempty"`
+	SentBy           string          `json:"sent_by,omitempty"`
+	CreatedAt        time.Time       `gorm:"default:now()" json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// WithdrawalActionDtoInput identifies the operator reviewing a withdrawal.
+type WithdrawalActionDtoInput struct {
+	OperatorId string `json:"operator_id"`
+}
+
+// WithdrawalPageDtoOutput is one page of the withdrawals, oldest first.
+type WithdrawalPageDtoOutput struct {
+	Withdrawals []Withdrawal `json:"withdrawals"`
+	Page        int          `json:"page"`
+	PageSize    int          `json:"page_size"`
+	Total       int64        `json:"total"`
+}
+
+// RequiresSecondApproval reports whether the amount is above what a single operator can approve.
+func (w Withdrawal) RequiresSecondApproval() bool {
+	return w.Amount.GreaterThan(WithdrawalApprovalThreshold(w.Asset))
+}
+
+// Approve records the approval of the operator. A withdrawal above the approval
+// threshold stays REQUESTED until a second, different operator approves it.
+func (w Withdrawal) Approve(operatorId string) (Withdrawal, error) {
+	if operatorId == "" {
+		return w, ErrorInvalidOperator
+	}
+
+	if w.Status != WithdrawalRequested {
+		return w, ErrorInvalidApproveWithdrawal
+	}
+
+	if w.ApprovedBy == "" {
+		w.ApprovedBy = operatorId
+		if !w.RequiresSecondApproval() {
+			w.Status = WithdrawalApproved
+		}
+		return w, nil
+	}
+
+	if w.ApprovedBy == operatorId {
+		return w, ErrorSameOperatorApproval
+	}
+
+	w.SecondApprovedBy = operatorId
+	w.Status = WithdrawalApproved
+	return w, nil
+}
+
+// Reject closes a withdrawal that was not sent yet.
+func (w Withdrawal) Reject(operatorId string) (Withdrawal, error) {
+	if operatorId == "" {
+		return w, ErrorInvalidOperator
+	}
+
+	if w.Status != WithdrawalRequested && w.Status != WithdrawalApproved {
+		return w, ErrorInvalidRejectWithdrawal
+	}
+
+	w.RejectedBy = operatorId
+	w.Status = WithdrawalRejected
+	return w, nil
+}
+
+// Send records that an approved withdrawal left the exchange.
+func (w Withdrawal) Send(operatorId string) (Withdrawal, error) {
+	if operatorId == "" {
+		return w, ErrorInvalidOperator
+	}
+
+	if w.Status != WithdrawalApproved {
+		return w, ErrorInvalidSendWithdrawal
+	}
+
+	w.SentBy = operatorId
+	w.Status = WithdrawalSent
+	return w, nil
+}